@@ -36,7 +36,7 @@ func main() {
 	fmt.Printf("ID: %d - Name: %s - Public: %v\n", node.ID, node.Name, node.Public)
 
 	data := node.UpdateDescriptor()
-	data.Public = false
+	data.Public = croc.Bool(false)
 	node, err = app.UpdateNode(node.ID, *data)
 	if err != nil {
 		handleError(err)
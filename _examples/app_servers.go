@@ -23,7 +23,7 @@ func main() {
 		},
 		Limits:        &croc.Limits{1024, 0, 1024, 10, 1, "0", false},
 		FeatureLimtis: croc.FeatureLimits{1, 0, 0},
-		Deploy:        &croc.DeployDescriptor{[]int{1, 2}, false, []string{}},
+		Deploy:        &croc.DeployDescriptor{Locations: []int{1, 2}, PortRange: []string{}},
 	})
 	if err != nil {
 		handleError(err)
@@ -33,7 +33,7 @@ func main() {
 	fmt.Printf("ID: %d - Name: %s - ExternalID: %s\n", server.ID, server.Name, server.ExternalID)
 
 	data := server.DetailsDescriptor()
-	data.ExternalID = "croc"
+	data.ExternalID = croc.String("croc")
 	server, err = app.UpdateServerDetails(server.ID, *data)
 	if err != nil {
 		handleError(err)
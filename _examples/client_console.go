@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func main() {
+	client, _ := croc.NewClient(os.Getenv("CROC_URL"), os.Getenv("CROC_KEY"))
+
+	console := client.NewConsole(os.Getenv("CROC_SERVER"))
+	console.OnOutput = func(line string) {
+		fmt.Println(line)
+	}
+	console.OnStatus = func(state croc.ServerState) {
+		fmt.Printf("server is now %s\n", state)
+	}
+
+	if err := console.Connect(); err != nil {
+		handleError(err)
+		return
+	}
+	defer console.Close()
+
+	if err := console.RequestLogs(); err != nil {
+		handleError(err)
+		return
+	}
+
+	if err := console.SendCommand("say \"hello world\""); err != nil {
+		handleError(err)
+		return
+	}
+
+	<-console.Done()
+	if err := console.Err(); err != nil {
+		handleError(err)
+	}
+}
+
+func handleError(err error) {
+	if errs, ok := err.(*croc.ApiError); ok {
+		for _, e := range errs.Errors {
+			fmt.Println(e.Error())
+		}
+	} else {
+		fmt.Println(err.Error())
+	}
+}
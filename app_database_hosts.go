@@ -0,0 +1,227 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DatabaseHost is a database server the panel creates server databases on,
+// the endpoints are only available on Pelican.
+type DatabaseHost struct {
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	Host         string     `json:"host"`
+	Port         int        `json:"port"`
+	Username     string     `json:"username"`
+	MaxDatabases int        `json:"max_databases"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	Extra        Extra      `json:"-"`
+}
+
+func (h *DatabaseHost) UnmarshalJSON(buf []byte) error {
+	type plain DatabaseHost
+	extra, err := unmarshalExtra(buf, (*plain)(h))
+	h.Extra = extra
+	return err
+}
+
+func (h DatabaseHost) MarshalJSON() ([]byte, error) {
+	type plain DatabaseHost
+	return marshalExtra(plain(h), h.Extra)
+}
+
+func (h *DatabaseHost) UpdateDescriptor() *UpdateDatabaseHostDescriptor {
+	return &UpdateDatabaseHostDescriptor{
+		Name:         String(h.Name),
+		Host:         String(h.Host),
+		Port:         Int(h.Port),
+		Username:     String(h.Username),
+		MaxDatabases: Int(h.MaxDatabases),
+	}
+}
+
+// DiffUpdateDescriptor returns the update that turns the host into the
+// desired one, and whether anything differs. The name, host, port and
+// username are always included because the panel requires them on every
+// update. The password is not part of a host and never changed.
+func (h *DatabaseHost) DiffUpdateDescriptor(desired *DatabaseHost) (*UpdateDatabaseHostDescriptor, bool) {
+	d := &UpdateDatabaseHostDescriptor{
+		Name:     String(desired.Name),
+		Host:     String(desired.Host),
+		Port:     Int(desired.Port),
+		Username: String(desired.Username),
+	}
+	changed := h.Name != desired.Name || h.Host != desired.Host ||
+		h.Port != desired.Port || h.Username != desired.Username
+
+	if h.MaxDatabases != desired.MaxDatabases {
+		d.MaxDatabases = Int(desired.MaxDatabases)
+		changed = true
+	}
+
+	return d, changed
+}
+
+type DatabaseHostDescriptor struct {
+	Name         string `json:"name"`
+	Host         string `json:"host"`
+	Port         int    `json:"port"`
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	MaxDatabases int    `json:"max_databases,omitempty"`
+	Nodes        []int  `json:"node_ids,omitempty"`
+}
+
+func (a *Application) GetDatabaseHosts() ([]*DatabaseHost, error) {
+	if err := a.RequireCapability(CapabilityDatabaseHosts); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", "/database-hosts", nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *DatabaseHost `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	hosts := make([]*DatabaseHost, 0, len(model.Data))
+	for _, d := range model.Data {
+		hosts = append(hosts, d.Attributes)
+	}
+
+	return hosts, nil
+}
+
+func (a *Application) GetDatabaseHost(id int) (*DatabaseHost, error) {
+	if err := a.RequireCapability(CapabilityDatabaseHosts); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", fmt.Sprintf("/database-hosts/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes DatabaseHost `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) CreateDatabaseHost(fields DatabaseHostDescriptor) (*DatabaseHost, error) {
+	if err := a.RequireCapability(CapabilityDatabaseHosts); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(fields)
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("POST", "/database-hosts", &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes DatabaseHost `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+// UpdateDatabaseHostDescriptor holds the fields to update, nil fields are
+// left out. The nodes are only replaced when the list is not empty.
+type UpdateDatabaseHostDescriptor struct {
+	Name         *string `json:"name,omitempty"`
+	Host         *string `json:"host,omitempty"`
+	Port         *int    `json:"port,omitempty"`
+	Username     *string `json:"username,omitempty"`
+	Password     *string `json:"password,omitempty"`
+	MaxDatabases *int    `json:"max_databases,omitempty"`
+	Nodes        []int   `json:"node_ids,omitempty"`
+}
+
+func (a *Application) UpdateDatabaseHost(id int, fields UpdateDatabaseHostDescriptor) (*DatabaseHost, error) {
+	if err := a.RequireCapability(CapabilityDatabaseHosts); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(fields)
+	if len(data) == 2 {
+		return nil, errors.New("no update fields specified")
+	}
+
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("PATCH", fmt.Sprintf("/database-hosts/%d", id), &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes DatabaseHost `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) DeleteDatabaseHost(id int) error {
+	if err := a.RequireCapability(CapabilityDatabaseHosts); err != nil {
+		return err
+	}
+
+	req := a.newRequest("DELETE", fmt.Sprintf("/database-hosts/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
@@ -0,0 +1,187 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Nest groups eggs on Pterodactyl, Pelican has no nests and lists eggs on
+// their own.
+type Nest struct {
+	ID          int        `json:"id"`
+	UUID        string     `json:"uuid"`
+	Author      string     `json:"author"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	CreatedAt   *time.Time `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	Extra       Extra      `json:"-"`
+}
+
+func (n *Nest) UnmarshalJSON(buf []byte) error {
+	type plain Nest
+	extra, err := unmarshalExtra(buf, (*plain)(n))
+	n.Extra = extra
+	return err
+}
+
+func (n Nest) MarshalJSON() ([]byte, error) {
+	type plain Nest
+	return marshalExtra(plain(n), n.Extra)
+}
+
+// Egg describes how to install and run a server. Nest is zero on Pelican.
+type Egg struct {
+	ID           int               `json:"id"`
+	UUID         string            `json:"uuid"`
+	Name         string            `json:"name"`
+	Nest         int               `json:"nest,omitempty"`
+	Author       string            `json:"author"`
+	Description  string            `json:"description"`
+	DockerImage  string            `json:"docker_image,omitempty"`
+	DockerImages map[string]string `json:"docker_images"`
+	Startup      string            `json:"startup"`
+	CreatedAt    *time.Time        `json:"created_at"`
+	UpdatedAt    *time.Time        `json:"updated_at,omitempty"`
+	Extra        Extra             `json:"-"`
+}
+
+func (e *Egg) UnmarshalJSON(buf []byte) error {
+	type plain Egg
+	extra, err := unmarshalExtra(buf, (*plain)(e))
+	e.Extra = extra
+	return err
+}
+
+func (e Egg) MarshalJSON() ([]byte, error) {
+	type plain Egg
+	return marshalExtra(plain(e), e.Extra)
+}
+
+func (a *Application) GetNests() ([]*Nest, error) {
+	if err := a.RequireCapability(CapabilityNests); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", "/nests", nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *Nest `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	nests := make([]*Nest, 0, len(model.Data))
+	for _, n := range model.Data {
+		nests = append(nests, n.Attributes)
+	}
+
+	return nests, nil
+}
+
+func (a *Application) GetNestEggs(nest int) ([]*Egg, error) {
+	if err := a.RequireCapability(CapabilityNests); err != nil {
+		return nil, err
+	}
+
+	return a.getEggs(fmt.Sprintf("/nests/%d/eggs", nest))
+}
+
+// GetEggs lists the eggs of every nest on Pterodactyl and the eggs endpoint on
+// Pelican.
+func (a *Application) GetEggs() ([]*Egg, error) {
+	if err := a.RequireCapability(CapabilityEggs); err == nil {
+		return a.getEggs("/eggs")
+	} else if _, ok := err.(*UnsupportedError); !ok {
+		return nil, err
+	}
+
+	nests, err := a.GetNests()
+	if err != nil {
+		return nil, err
+	}
+
+	var eggs []*Egg
+	for _, n := range nests {
+		found, err := a.getEggs(fmt.Sprintf("/nests/%d/eggs", n.ID))
+		if err != nil {
+			return nil, err
+		}
+		eggs = append(eggs, found...)
+	}
+
+	return eggs, nil
+}
+
+func (a *Application) getEggs(path string) ([]*Egg, error) {
+	req := a.newRequest("GET", path, nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *Egg `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	eggs := make([]*Egg, 0, len(model.Data))
+	for _, e := range model.Data {
+		eggs = append(eggs, e.Attributes)
+	}
+
+	return eggs, nil
+}
+
+// GetEgg returns an egg by id, Pterodactyl also needs the nest which is
+// ignored on Pelican.
+func (a *Application) GetEgg(nest, id int) (*Egg, error) {
+	path := fmt.Sprintf("/nests/%d/eggs/%d", nest, id)
+	if err := a.RequireCapability(CapabilityEggs); err == nil {
+		path = fmt.Sprintf("/eggs/%d", id)
+	} else if _, ok := err.(*UnsupportedError); !ok {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", path, nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Egg `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
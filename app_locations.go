@@ -13,9 +13,26 @@ type Location struct {
 	Long      string     `json:"long"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Extra     Extra      `json:"-"`
+}
+
+func (l *Location) UnmarshalJSON(buf []byte) error {
+	type plain Location
+	extra, err := unmarshalExtra(buf, (*plain)(l))
+	l.Extra = extra
+	return err
+}
+
+func (l Location) MarshalJSON() ([]byte, error) {
+	type plain Location
+	return marshalExtra(plain(l), l.Extra)
 }
 
 func (a *Application) GetLocations() ([]*Location, error) {
+	if err := a.knownUnsupported(CapabilityLocations); err != nil {
+		return nil, err
+	}
+
 	req := a.newRequest("GET", "/locations", nil)
 	res, err := a.Http.Do(req)
 	if err != nil {
@@ -45,6 +62,10 @@ func (a *Application) GetLocations() ([]*Location, error) {
 }
 
 func (a *Application) GetLocation(id int) (*Location, error) {
+	if err := a.knownUnsupported(CapabilityLocations); err != nil {
+		return nil, err
+	}
+
 	req := a.newRequest("GET", fmt.Sprintf("/locations/%d", id), nil)
 	res, err := a.Http.Do(req)
 	if err != nil {
@@ -67,6 +88,10 @@ func (a *Application) GetLocation(id int) (*Location, error) {
 }
 
 func (a *Application) CreateLocation(short, long string) (*Location, error) {
+	if err := a.knownUnsupported(CapabilityLocations); err != nil {
+		return nil, err
+	}
+
 	data, _ := json.Marshal(map[string]string{"short": short, "long": long})
 	body := bytes.Buffer{}
 	body.Write(data)
@@ -93,6 +118,10 @@ func (a *Application) CreateLocation(short, long string) (*Location, error) {
 }
 
 func (a *Application) UpdateLocation(id int, short, long string) (*Location, error) {
+	if err := a.knownUnsupported(CapabilityLocations); err != nil {
+		return nil, err
+	}
+
 	data, _ := json.Marshal(map[string]string{"short": short, "long": long})
 	body := bytes.Buffer{}
 	body.Write(data)
@@ -119,6 +148,10 @@ func (a *Application) UpdateLocation(id int, short, long string) (*Location, err
 }
 
 func (a *Application) DeleteLocation(id int) error {
+	if err := a.knownUnsupported(CapabilityLocations); err != nil {
+		return err
+	}
+
 	req := a.newRequest("DELETE", fmt.Sprintf("/locations/%d", id), nil)
 	res, err := a.Http.Do(req)
 	if err != nil {
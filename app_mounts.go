@@ -0,0 +1,311 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Mount is a directory of the node that can be mounted into servers, the
+// endpoints are only available on Pelican.
+type Mount struct {
+	ID            int        `json:"id"`
+	UUID          string     `json:"uuid"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Source        string     `json:"source"`
+	Target        string     `json:"target"`
+	ReadOnly      bool       `json:"read_only"`
+	UserMountable bool       `json:"user_mountable"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`
+	Extra         Extra      `json:"-"`
+}
+
+func (m *Mount) UnmarshalJSON(buf []byte) error {
+	type plain Mount
+	extra, err := unmarshalExtra(buf, (*plain)(m))
+	m.Extra = extra
+	return err
+}
+
+func (m Mount) MarshalJSON() ([]byte, error) {
+	type plain Mount
+	return marshalExtra(plain(m), m.Extra)
+}
+
+func (m *Mount) Descriptor() *MountDescriptor {
+	return &MountDescriptor{
+		Name:          m.Name,
+		Description:   m.Description,
+		Source:        m.Source,
+		Target:        m.Target,
+		ReadOnly:      m.ReadOnly,
+		UserMountable: m.UserMountable,
+	}
+}
+
+func (m *Mount) UpdateDescriptor() *UpdateMountDescriptor {
+	return &UpdateMountDescriptor{
+		Name:          String(m.Name),
+		Description:   String(m.Description),
+		Source:        String(m.Source),
+		Target:        String(m.Target),
+		ReadOnly:      Bool(m.ReadOnly),
+		UserMountable: Bool(m.UserMountable),
+	}
+}
+
+// DiffUpdateDescriptor returns the update that turns the mount into the
+// desired one, and whether anything differs. The name, source and target are
+// always included because the panel requires them on every update.
+func (m *Mount) DiffUpdateDescriptor(desired *Mount) (*UpdateMountDescriptor, bool) {
+	d := &UpdateMountDescriptor{
+		Name:   String(desired.Name),
+		Source: String(desired.Source),
+		Target: String(desired.Target),
+	}
+	changed := m.Name != desired.Name || m.Source != desired.Source || m.Target != desired.Target
+
+	if m.Description != desired.Description {
+		d.Description = String(desired.Description)
+		changed = true
+	}
+	if m.ReadOnly != desired.ReadOnly {
+		d.ReadOnly = Bool(desired.ReadOnly)
+		changed = true
+	}
+	if m.UserMountable != desired.UserMountable {
+		d.UserMountable = Bool(desired.UserMountable)
+		changed = true
+	}
+
+	return d, changed
+}
+
+type MountDescriptor struct {
+	Name          string `json:"name"`
+	Description   string `json:"description,omitempty"`
+	Source        string `json:"source"`
+	Target        string `json:"target"`
+	ReadOnly      bool   `json:"read_only"`
+	UserMountable bool   `json:"user_mountable"`
+}
+
+func (a *Application) GetMounts() ([]*Mount, error) {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", "/mounts", nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *Mount `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	mounts := make([]*Mount, 0, len(model.Data))
+	for _, d := range model.Data {
+		mounts = append(mounts, d.Attributes)
+	}
+
+	return mounts, nil
+}
+
+func (a *Application) GetMount(id int) (*Mount, error) {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", fmt.Sprintf("/mounts/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Mount `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) CreateMount(fields MountDescriptor) (*Mount, error) {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(fields)
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("POST", "/mounts", &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Mount `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+// UpdateMountDescriptor holds the fields to update, nil fields are left out.
+type UpdateMountDescriptor struct {
+	Name          *string `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Source        *string `json:"source,omitempty"`
+	Target        *string `json:"target,omitempty"`
+	ReadOnly      *bool   `json:"read_only,omitempty"`
+	UserMountable *bool   `json:"user_mountable,omitempty"`
+}
+
+func (a *Application) UpdateMount(id int, fields UpdateMountDescriptor) (*Mount, error) {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(fields)
+	if len(data) == 2 {
+		return nil, errors.New("no update fields specified")
+	}
+
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("PATCH", fmt.Sprintf("/mounts/%d", id), &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Mount `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) DeleteMount(id int) error {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return err
+	}
+
+	req := a.newRequest("DELETE", fmt.Sprintf("/mounts/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (a *Application) AddMountEggs(id int, eggs ...int) error {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return err
+	}
+
+	data, _ := json.Marshal(map[string][]int{"eggs": eggs})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("POST", fmt.Sprintf("/mounts/%d/eggs", id), &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (a *Application) AddMountNodes(id int, nodes ...int) error {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return err
+	}
+
+	data, _ := json.Marshal(map[string][]int{"nodes": nodes})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("POST", fmt.Sprintf("/mounts/%d/nodes", id), &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (a *Application) RemoveMountEgg(id, egg int) error {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return err
+	}
+
+	req := a.newRequest("DELETE", fmt.Sprintf("/mounts/%d/eggs/%d", id, egg), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (a *Application) RemoveMountNode(id, node int) error {
+	if err := a.RequireCapability(CapabilityMounts); err != nil {
+		return err
+	}
+
+	req := a.newRequest("DELETE", fmt.Sprintf("/mounts/%d/nodes/%d", id, node), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
@@ -28,28 +28,93 @@ type Node struct {
 	UploadSize         int64      `json:"upload_size"`
 	CreatedAt          *time.Time `json:"created_at"`
 	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
+	Extra              Extra      `json:"-"`
+}
+
+func (n *Node) UnmarshalJSON(buf []byte) error {
+	type plain Node
+	extra, err := unmarshalExtra(buf, (*plain)(n))
+	n.Extra = extra
+	return err
+}
+
+func (n Node) MarshalJSON() ([]byte, error) {
+	type plain Node
+	return marshalExtra(plain(n), n.Extra)
 }
 
 func (n *Node) UpdateDescriptor() *UpdateNodeDescriptor {
 	return &UpdateNodeDescriptor{
-		Name:               n.Name,
-		Description:        n.Description,
-		LocationID:         n.LocationID,
-		Public:             n.Public,
-		FQDN:               n.FQDN,
-		Scheme:             n.Scheme,
-		BehindProxy:        n.BehindProxy,
-		Memory:             n.Memory,
-		MemoryOverallocate: n.MemoryOverallocate,
-		Disk:               n.Disk,
-		DiskOverallocate:   n.DiskOverallocate,
-		DaemonBase:         n.DaemonBase,
-		DaemonSftp:         n.DaemonSftp,
-		DaemonListen:       n.DaemonListen,
-		UploadSize:         n.UploadSize,
+		Name:               String(n.Name),
+		Description:        String(n.Description),
+		LocationID:         Int(n.LocationID),
+		Public:             Bool(n.Public),
+		FQDN:               String(n.FQDN),
+		Scheme:             String(n.Scheme),
+		BehindProxy:        Bool(n.BehindProxy),
+		Memory:             Int64(n.Memory),
+		MemoryOverallocate: Int64(n.MemoryOverallocate),
+		Disk:               Int64(n.Disk),
+		DiskOverallocate:   Int64(n.DiskOverallocate),
+		DaemonBase:         String(n.DaemonBase),
+		DaemonSftp:         Int32(n.DaemonSftp),
+		DaemonListen:       Int32(n.DaemonListen),
+		MaintenanceMode:    Bool(n.MaintenanceMode),
+		UploadSize:         Int64(n.UploadSize),
 	}
 }
 
+// DiffUpdateDescriptor returns the update that turns the node into the desired
+// one, and whether anything differs. The name, location, connection and
+// resource fields are always included because the panel requires them on
+// every update.
+func (n *Node) DiffUpdateDescriptor(desired *Node) (*UpdateNodeDescriptor, bool) {
+	d := &UpdateNodeDescriptor{
+		Name:               String(desired.Name),
+		LocationID:         Int(desired.LocationID),
+		FQDN:               String(desired.FQDN),
+		Scheme:             String(desired.Scheme),
+		Memory:             Int64(desired.Memory),
+		MemoryOverallocate: Int64(desired.MemoryOverallocate),
+		Disk:               Int64(desired.Disk),
+		DiskOverallocate:   Int64(desired.DiskOverallocate),
+		DaemonSftp:         Int32(desired.DaemonSftp),
+		DaemonListen:       Int32(desired.DaemonListen),
+	}
+	changed := n.Name != desired.Name || n.LocationID != desired.LocationID ||
+		n.FQDN != desired.FQDN || n.Scheme != desired.Scheme ||
+		n.Memory != desired.Memory || n.MemoryOverallocate != desired.MemoryOverallocate ||
+		n.Disk != desired.Disk || n.DiskOverallocate != desired.DiskOverallocate ||
+		n.DaemonSftp != desired.DaemonSftp || n.DaemonListen != desired.DaemonListen
+
+	if n.Description != desired.Description {
+		d.Description = String(desired.Description)
+		changed = true
+	}
+	if n.Public != desired.Public {
+		d.Public = Bool(desired.Public)
+		changed = true
+	}
+	if n.BehindProxy != desired.BehindProxy {
+		d.BehindProxy = Bool(desired.BehindProxy)
+		changed = true
+	}
+	if n.DaemonBase != desired.DaemonBase {
+		d.DaemonBase = String(desired.DaemonBase)
+		changed = true
+	}
+	if n.MaintenanceMode != desired.MaintenanceMode {
+		d.MaintenanceMode = Bool(desired.MaintenanceMode)
+		changed = true
+	}
+	if n.UploadSize != desired.UploadSize {
+		d.UploadSize = Int64(desired.UploadSize)
+		changed = true
+	}
+
+	return d, changed
+}
+
 func (a *Application) GetNodes() ([]*Node, error) {
 	req := a.newRequest("GET", "/nodes", nil)
 	res, err := a.Http.Do(req)
@@ -164,6 +229,19 @@ type NodeConfiguration struct {
 	} `json:"system"`
 	AllowedMounts []string `json:"allowed_mounts"`
 	Remote        string   `json:"remote"`
+	Extra         Extra    `json:"-"`
+}
+
+func (c *NodeConfiguration) UnmarshalJSON(buf []byte) error {
+	type plain NodeConfiguration
+	extra, err := unmarshalExtra(buf, (*plain)(c))
+	c.Extra = extra
+	return err
+}
+
+func (c NodeConfiguration) MarshalJSON() ([]byte, error) {
+	type plain NodeConfiguration
+	return marshalExtra(plain(c), c.Extra)
 }
 
 func (a *Application) GetNodeConfiguration(id int) (*NodeConfiguration, error) {
@@ -230,22 +308,24 @@ func (a *Application) CreateNode(fields CreateNodeDescriptor) (*Node, error) {
 	return &model.Attributes, nil
 }
 
+// UpdateNodeDescriptor holds the fields to update, nil fields are left out.
 type UpdateNodeDescriptor struct {
-	Name               string `json:"name"`
-	Description        string `json:"description"`
-	LocationID         int    `json:"location_id"`
-	Public             bool   `json:"public"`
-	FQDN               string `json:"fqdn"`
-	Scheme             string `json:"scheme"`
-	BehindProxy        bool   `json:"behind_proxy"`
-	Memory             int64  `json:"memory"`
-	MemoryOverallocate int64  `json:"memory_overallocate"`
-	Disk               int64  `json:"disk"`
-	DiskOverallocate   int64  `json:"disk_overallocate"`
-	DaemonBase         string `json:"daemon_base"`
-	DaemonSftp         int32  `json:"daemon_sftp"`
-	DaemonListen       int32  `json:"daemon_listen"`
-	UploadSize         int64  `json:"upload_size"`
+	Name               *string `json:"name,omitempty"`
+	Description        *string `json:"description,omitempty"`
+	LocationID         *int    `json:"location_id,omitempty"`
+	Public             *bool   `json:"public,omitempty"`
+	FQDN               *string `json:"fqdn,omitempty"`
+	Scheme             *string `json:"scheme,omitempty"`
+	BehindProxy        *bool   `json:"behind_proxy,omitempty"`
+	Memory             *int64  `json:"memory,omitempty"`
+	MemoryOverallocate *int64  `json:"memory_overallocate,omitempty"`
+	Disk               *int64  `json:"disk,omitempty"`
+	DiskOverallocate   *int64  `json:"disk_overallocate,omitempty"`
+	DaemonBase         *string `json:"daemon_base,omitempty"`
+	DaemonSftp         *int32  `json:"daemon_sftp,omitempty"`
+	DaemonListen       *int32  `json:"daemon_listen,omitempty"`
+	MaintenanceMode    *bool   `json:"maintenance_mode,omitempty"`
+	UploadSize         *int64  `json:"upload_size,omitempty"`
 }
 
 func (a *Application) UpdateNode(id int, fields UpdateNodeDescriptor) (*Node, error) {
@@ -296,6 +376,19 @@ type Allocation struct {
 	Port     int32  `json:"port"`
 	Notes    string `json:"notes,omitempty"`
 	Assigned bool   `json:"assigned"`
+	Extra    Extra  `json:"-"`
+}
+
+func (a *Allocation) UnmarshalJSON(buf []byte) error {
+	type plain Allocation
+	extra, err := unmarshalExtra(buf, (*plain)(a))
+	a.Extra = extra
+	return err
+}
+
+func (a Allocation) MarshalJSON() ([]byte, error) {
+	type plain Allocation
+	return marshalExtra(plain(a), a.Extra)
 }
 
 func (a *Application) GetNodeAllocations(node int) ([]*Allocation, error) {
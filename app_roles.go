@@ -0,0 +1,164 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Role is an admin role, the endpoints are only available on Pelican.
+type Role struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	GuardName string     `json:"guard_name"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Extra     Extra      `json:"-"`
+}
+
+func (r *Role) UnmarshalJSON(buf []byte) error {
+	type plain Role
+	extra, err := unmarshalExtra(buf, (*plain)(r))
+	r.Extra = extra
+	return err
+}
+
+func (r Role) MarshalJSON() ([]byte, error) {
+	type plain Role
+	return marshalExtra(plain(r), r.Extra)
+}
+
+func (a *Application) GetRoles() ([]*Role, error) {
+	if err := a.RequireCapability(CapabilityRoles); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", "/roles", nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *Role `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	roles := make([]*Role, 0, len(model.Data))
+	for _, d := range model.Data {
+		roles = append(roles, d.Attributes)
+	}
+
+	return roles, nil
+}
+
+func (a *Application) GetRole(id int) (*Role, error) {
+	if err := a.RequireCapability(CapabilityRoles); err != nil {
+		return nil, err
+	}
+
+	req := a.newRequest("GET", fmt.Sprintf("/roles/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Role `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) CreateRole(name string) (*Role, error) {
+	if err := a.RequireCapability(CapabilityRoles); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(map[string]string{"name": name})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("POST", "/roles", &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Role `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) UpdateRole(id int, name string) (*Role, error) {
+	if err := a.RequireCapability(CapabilityRoles); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(map[string]string{"name": name})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := a.newRequest("PATCH", fmt.Sprintf("/roles/%d", id), &body)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes Role `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (a *Application) DeleteRole(id int) error {
+	if err := a.RequireCapability(CapabilityRoles); err != nil {
+		return err
+	}
+
+	req := a.newRequest("DELETE", fmt.Sprintf("/roles/%d", id), nil)
+	res, err := a.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
@@ -15,7 +15,7 @@ type AppServer struct {
 	Identifier    string        `json:"identifier"`
 	Name          string        `json:"name"`
 	Description   string        `json:"description"`
-	Status        string        `json:"status,omitempty"`
+	Status        ServerStatus  `json:"status,omitempty"`
 	Suspended     bool          `json:"suspended"`
 	Limits        Limits        `json:"limits"`
 	FeatureLimits FeatureLimits `json:"feature_limits"`
@@ -32,37 +32,117 @@ type AppServer struct {
 	} `json:"container"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	Extra     Extra      `json:"-"`
+}
+
+func (s *AppServer) UnmarshalJSON(buf []byte) error {
+	type plain AppServer
+	extra, err := unmarshalExtra(buf, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s AppServer) MarshalJSON() ([]byte, error) {
+	type plain AppServer
+	return marshalExtra(plain(s), s.Extra)
 }
 
 func (s *AppServer) BuildDescriptor() *ServerBuildDescriptor {
+	limits, features := s.Limits, s.FeatureLimits
 	return &ServerBuildDescriptor{
-		Allocation:        s.Allocation,
-		OOMDisabled:       s.Limits.OOMDisabled,
-		Limits:            s.Limits,
-		AddAllocations:    []int{},
-		RemoveAllocations: []int{},
-		FeatureLimits:     s.FeatureLimits,
+		Allocation:    Int(s.Allocation),
+		OOMDisabled:   Bool(s.Limits.OOMDisabled),
+		Limits:        &limits,
+		FeatureLimits: &features,
 	}
 }
 
+// DiffBuildDescriptor returns the build update that turns the server into the
+// desired one, and whether anything differs. The allocation, limits and
+// feature limits are always included because the panel requires them on
+// every update.
+func (s *AppServer) DiffBuildDescriptor(desired *AppServer) (*ServerBuildDescriptor, bool) {
+	limits, features := desired.Limits, desired.FeatureLimits
+	d := &ServerBuildDescriptor{
+		Allocation:    Int(desired.Allocation),
+		Limits:        &limits,
+		FeatureLimits: &features,
+	}
+	changed := s.Allocation != desired.Allocation || s.Limits != desired.Limits ||
+		s.FeatureLimits != desired.FeatureLimits
+
+	if s.Limits.OOMDisabled != desired.Limits.OOMDisabled {
+		d.OOMDisabled = Bool(desired.Limits.OOMDisabled)
+		changed = true
+	}
+
+	return d, changed
+}
+
 func (s *AppServer) DetailsDescriptor() *ServerDetailsDescriptor {
 	return &ServerDetailsDescriptor{
-		ExternalID:  s.ExternalID,
-		Name:        s.Name,
-		User:        s.User,
-		Description: s.Description,
+		ExternalID:  String(s.ExternalID),
+		Name:        String(s.Name),
+		User:        Int(s.User),
+		Description: String(s.Description),
 	}
 }
 
+// DiffDetailsDescriptor returns the details update that turns the server into
+// the desired one, and whether anything differs. Every field is included, the
+// panel requires the name and user and clears the external id and
+// description when they are left out.
+func (s *AppServer) DiffDetailsDescriptor(desired *AppServer) (*ServerDetailsDescriptor, bool) {
+	d := desired.DetailsDescriptor()
+	changed := s.Name != desired.Name || s.User != desired.User ||
+		s.ExternalID != desired.ExternalID || s.Description != desired.Description
+
+	return d, changed
+}
+
 func (s *AppServer) StartupDescriptor() *ServerStartupDescriptor {
 	return &ServerStartupDescriptor{
-		Startup:     s.Container.StartupCommand,
+		Startup:     String(s.Container.StartupCommand),
 		Environment: s.Container.Environment,
-		Egg:         s.Egg,
-		Image:       s.Container.Image,
+		Egg:         Int(s.Egg),
+		Image:       String(s.Container.Image),
 	}
 }
 
+// DiffStartupDescriptor returns the startup update that turns the server into
+// the desired one, and whether anything differs. The environment is always
+// the full one of the current server with the desired variables applied, as
+// the panel checks every egg variable on each update.
+func (s *AppServer) DiffStartupDescriptor(desired *AppServer) (*ServerStartupDescriptor, bool) {
+	d := &ServerStartupDescriptor{Environment: map[string]interface{}{}}
+	changed := false
+
+	for k, v := range s.Container.Environment {
+		d.Environment[k] = v
+	}
+
+	if s.Container.StartupCommand != desired.Container.StartupCommand {
+		d.Startup = String(desired.Container.StartupCommand)
+		changed = true
+	}
+	if s.Egg != desired.Egg {
+		d.Egg = Int(desired.Egg)
+		changed = true
+	}
+	if s.Container.Image != desired.Container.Image {
+		d.Image = String(desired.Container.Image)
+		changed = true
+	}
+	for k, v := range desired.Container.Environment {
+		if old, ok := s.Container.Environment[k]; !ok || fmt.Sprint(old) != fmt.Sprint(v) {
+			changed = true
+		}
+		d.Environment[k] = v
+	}
+
+	return d, changed
+}
+
 func (a *Application) GetServers() ([]*AppServer, error) {
 	req := a.newRequest("GET", "/servers", nil)
 	res, err := a.Http.Do(req)
@@ -141,8 +221,11 @@ type AllocationDescriptor struct {
 	Additional []int `json:"additional,omitempty"`
 }
 
+// DeployDescriptor selects a node for the server, Pelican uses node tags
+// instead of locations.
 type DeployDescriptor struct {
 	Locations   []int    `json:"locations"`
+	Tags        []string `json:"tags,omitempty"`
 	DedicatedIP bool     `json:"dedicated_ip"`
 	PortRange   []string `json:"port_range"`
 }
@@ -195,13 +278,15 @@ func (a *Application) CreateServer(fields CreateServerDescriptor) (*AppServer, e
 	return &model.Attributes, nil
 }
 
+// ServerBuildDescriptor holds the build fields to update, nil fields are left
+// out.
 type ServerBuildDescriptor struct {
-	Allocation        int           `json:"allocation,omitempty"`
-	OOMDisabled       bool          `json:"oom_disabled,omitempty"`
-	Limits            Limits        `json:"limits,omitempty"`
-	AddAllocations    []int         `json:"add_allocations,omitempty"`
-	RemoveAllocations []int         `json:"remove_allocations,omitempty"`
-	FeatureLimits     FeatureLimits `json:"feature_limits,omitempty"`
+	Allocation        *int           `json:"allocation,omitempty"`
+	OOMDisabled       *bool          `json:"oom_disabled,omitempty"`
+	Limits            *Limits        `json:"limits,omitempty"`
+	AddAllocations    []int          `json:"add_allocations,omitempty"`
+	RemoveAllocations []int          `json:"remove_allocations,omitempty"`
+	FeatureLimits     *FeatureLimits `json:"feature_limits,omitempty"`
 }
 
 func (a *Application) UpdateServerBuild(id int, fields ServerBuildDescriptor) (*AppServer, error) {
@@ -234,11 +319,12 @@ func (a *Application) UpdateServerBuild(id int, fields ServerBuildDescriptor) (*
 	return &model.Attributes, nil
 }
 
+// ServerDetailsDescriptor holds the details to update, nil fields are left out.
 type ServerDetailsDescriptor struct {
-	ExternalID  string `json:"external_id,omitempty"`
-	Name        string `json:"name,omitempty"`
-	User        int    `json:"user,omitempty"`
-	Description string `json:"description,omitempty"`
+	ExternalID  *string `json:"external_id,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	User        *int    `json:"user,omitempty"`
+	Description *string `json:"description,omitempty"`
 }
 
 func (a *Application) UpdateServerDetails(id int, fields ServerDetailsDescriptor) (*AppServer, error) {
@@ -271,19 +357,27 @@ func (a *Application) UpdateServerDetails(id int, fields ServerDetailsDescriptor
 	return &model.Attributes, nil
 }
 
+// ServerStartupDescriptor holds the startup fields to update, nil fields are
+// left out. The environment and SkipScripts are always sent as the panel
+// requires them, and the environment must hold every variable the egg
+// requires or the update is rejected.
 type ServerStartupDescriptor struct {
-	Startup     string                 `json:"startup"`
+	Startup     *string                `json:"startup,omitempty"`
 	Environment map[string]interface{} `json:"environment"`
-	Egg         int                    `json:"egg,omitempty"`
-	Image       string                 `json:"image"`
+	Egg         *int                   `json:"egg,omitempty"`
+	Image       *string                `json:"image,omitempty"`
 	SkipScripts bool                   `json:"skip_scripts"`
 }
 
 func (a *Application) UpdateServerStartup(id int, fields ServerStartupDescriptor) (*AppServer, error) {
-	data, _ := json.Marshal(fields)
-	if len(data) == 2 {
+	if fields.Startup == nil && fields.Egg == nil && fields.Image == nil && len(fields.Environment) == 0 {
 		return nil, errors.New("no startup fields specified")
 	}
+	if fields.Environment == nil {
+		fields.Environment = map[string]interface{}{}
+	}
+
+	data, _ := json.Marshal(fields)
 
 	body := bytes.Buffer{}
 	body.Write(data)
@@ -3,6 +3,7 @@ package crocgodyl
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -20,6 +21,19 @@ type User struct {
 	TwoFactor  bool       `json:"2fa"`
 	CreatedAt  *time.Time `json:"created_at"`
 	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+	Extra      Extra      `json:"-"`
+}
+
+func (u *User) UnmarshalJSON(buf []byte) error {
+	type plain User
+	extra, err := unmarshalExtra(buf, (*plain)(u))
+	u.Extra = extra
+	return err
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+	type plain User
+	return marshalExtra(plain(u), u.Extra)
 }
 
 func (u *User) FullName() string {
@@ -28,16 +42,45 @@ func (u *User) FullName() string {
 
 func (u *User) UpdateDescriptor() *UpdateUserDescriptor {
 	return &UpdateUserDescriptor{
-		ExternalID: u.ExternalID,
-		Email:      u.Email,
-		Username:   u.Username,
-		FirstName:  u.FirstName,
-		LastName:   u.LastName,
-		Language:   u.Language,
-		RootAdmin:  u.RootAdmin,
+		ExternalID: String(u.ExternalID),
+		Email:      String(u.Email),
+		Username:   String(u.Username),
+		FirstName:  String(u.FirstName),
+		LastName:   String(u.LastName),
+		Language:   String(u.Language),
+		RootAdmin:  Bool(u.RootAdmin),
 	}
 }
 
+// DiffUpdateDescriptor returns the update that turns the user into the desired
+// one, and whether anything differs. The email, username and names are always
+// included because the panel requires them on every update.
+func (u *User) DiffUpdateDescriptor(desired *User) (*UpdateUserDescriptor, bool) {
+	d := &UpdateUserDescriptor{
+		Email:     String(desired.Email),
+		Username:  String(desired.Username),
+		FirstName: String(desired.FirstName),
+		LastName:  String(desired.LastName),
+	}
+	changed := u.Email != desired.Email || u.Username != desired.Username ||
+		u.FirstName != desired.FirstName || u.LastName != desired.LastName
+
+	if u.ExternalID != desired.ExternalID {
+		d.ExternalID = String(desired.ExternalID)
+		changed = true
+	}
+	if u.Language != desired.Language {
+		d.Language = String(desired.Language)
+		changed = true
+	}
+	if u.RootAdmin != desired.RootAdmin {
+		d.RootAdmin = Bool(desired.RootAdmin)
+		changed = true
+	}
+
+	return d, changed
+}
+
 func (a *Application) GetUsers() ([]*User, error) {
 	req := a.newRequest("GET", "/users", nil)
 	res, err := a.Http.Do(req)
@@ -148,19 +191,24 @@ func (a *Application) CreateUser(fields CreateUserDescriptor) (*User, error) {
 	return &model.Attributes, nil
 }
 
+// UpdateUserDescriptor holds the fields to update, nil fields are left out.
 type UpdateUserDescriptor struct {
-	ExternalID string `json:"external_id,omitempty"`
-	Email      string `json:"email,omitempty"`
-	Username   string `json:"username,omitempty"`
-	Password   string `json:"password,omitempty"`
-	FirstName  string `json:"first_name,omitempty"`
-	LastName   string `json:"last_name,omitempty"`
-	Language   string `json:"language,omitempty"`
-	RootAdmin  bool   `json:"root_admin,omitempty"`
+	ExternalID *string `json:"external_id,omitempty"`
+	Email      *string `json:"email,omitempty"`
+	Username   *string `json:"username,omitempty"`
+	Password   *string `json:"password,omitempty"`
+	FirstName  *string `json:"first_name,omitempty"`
+	LastName   *string `json:"last_name,omitempty"`
+	Language   *string `json:"language,omitempty"`
+	RootAdmin  *bool   `json:"root_admin,omitempty"`
 }
 
 func (a *Application) UpdateUser(id int, fields UpdateUserDescriptor) (*User, error) {
 	data, _ := json.Marshal(fields)
+	if len(data) == 2 {
+		return nil, errors.New("no update fields specified")
+	}
+
 	body := bytes.Buffer{}
 	body.Write(data)
 
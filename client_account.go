@@ -3,6 +3,8 @@ package crocgodyl
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"time"
 )
 
@@ -14,6 +16,19 @@ type Account struct {
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Language  string `json:"language"`
+	Extra     Extra  `json:"-"`
+}
+
+func (a *Account) UnmarshalJSON(buf []byte) error {
+	type plain Account
+	extra, err := unmarshalExtra(buf, (*plain)(a))
+	a.Extra = extra
+	return err
+}
+
+func (a Account) MarshalJSON() ([]byte, error) {
+	type plain Account
+	return marshalExtra(plain(a), a.Extra)
 }
 
 func (a *Account) FullName() string {
@@ -152,6 +167,19 @@ type ApiKey struct {
 	AllowedIPs  []string   `json:"allowed_ips"`
 	CreatedAt   *time.Time `json:"created_at"`
 	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	Extra       Extra      `json:"-"`
+}
+
+func (k *ApiKey) UnmarshalJSON(buf []byte) error {
+	type plain ApiKey
+	extra, err := unmarshalExtra(buf, (*plain)(k))
+	k.Extra = extra
+	return err
+}
+
+func (k ApiKey) MarshalJSON() ([]byte, error) {
+	type plain ApiKey
+	return marshalExtra(plain(k), k.Extra)
 }
 
 func (c *Client) GetApiKeys() ([]*ApiKey, error) {
@@ -222,3 +250,172 @@ func (c *Client) DeleteKey(identifier string) error {
 	_, err = validate(res)
 	return err
 }
+
+type SSHKey struct {
+	Name        string     `json:"name"`
+	Fingerprint string     `json:"fingerprint"`
+	PublicKey   string     `json:"public_key"`
+	CreatedAt   *time.Time `json:"created_at"`
+	Extra       Extra      `json:"-"`
+}
+
+func (k *SSHKey) UnmarshalJSON(buf []byte) error {
+	type plain SSHKey
+	extra, err := unmarshalExtra(buf, (*plain)(k))
+	k.Extra = extra
+	return err
+}
+
+func (k SSHKey) MarshalJSON() ([]byte, error) {
+	type plain SSHKey
+	return marshalExtra(plain(k), k.Extra)
+}
+
+func (c *Client) GetSSHKeys() ([]*SSHKey, error) {
+	req := c.newRequest("GET", "/account/ssh-keys", nil)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes *SSHKey `json:"attributes"`
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*SSHKey, 0, len(model.Data))
+	for _, k := range model.Data {
+		keys = append(keys, k.Attributes)
+	}
+
+	return keys, nil
+}
+
+func (c *Client) CreateSSHKey(name, publicKey string) (*SSHKey, error) {
+	key, err := ParseSSHPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = key.Comment
+	}
+	if name == "" {
+		return nil, errors.New("a name is required for the ssh key")
+	}
+
+	data, _ := json.Marshal(map[string]string{"name": name, "public_key": key.String()})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := c.newRequest("POST", "/account/ssh-keys", &body)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes SSHKey `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return &model.Attributes, nil
+}
+
+func (c *Client) DeleteSSHKey(fingerprint string) error {
+	data, _ := json.Marshal(map[string]string{"fingerprint": normalizeFingerprint(fingerprint)})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := c.newRequest("POST", "/account/ssh-keys/remove", &body)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+type SSHKeySyncResult struct {
+	Created   []*SSHKey
+	Deleted   []*SSHKey
+	Unchanged []*SSHKey
+}
+
+// SyncSSHKeys makes the account ssh keys match the keys in an authorized_keys
+// formatted reader. Keys are matched by fingerprint, so running it again with
+// the same input makes no changes. Keys that only exist on the panel are only
+// removed when prune is set.
+func (c *Client) SyncSSHKeys(r io.Reader, prune bool) (*SSHKeySyncResult, error) {
+	local, err := ParseAuthorizedKeys(r)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := c.GetSSHKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[string]*SSHKey, len(remote))
+	for _, k := range remote {
+		existing[normalizeFingerprint(k.Fingerprint)] = k
+	}
+
+	result := &SSHKeySyncResult{}
+	wanted := make(map[string]bool, len(local))
+	for _, k := range local {
+		fp := normalizeFingerprint(k.Fingerprint())
+		if wanted[fp] {
+			continue
+		}
+		wanted[fp] = true
+
+		if key, ok := existing[fp]; ok {
+			result.Unchanged = append(result.Unchanged, key)
+			continue
+		}
+
+		name := k.Comment
+		if name == "" {
+			name = k.Type + " " + fp[:12]
+		}
+
+		key, err := c.CreateSSHKey(name, k.String())
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, key)
+	}
+
+	if prune {
+		for fp, key := range existing {
+			if wanted[fp] {
+				continue
+			}
+			if err = c.DeleteSSHKey(key.Fingerprint); err != nil {
+				return result, err
+			}
+			result.Deleted = append(result.Deleted, key)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,326 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Pagination struct {
+	Total       int `json:"total"`
+	Count       int `json:"count"`
+	PerPage     int `json:"per_page"`
+	CurrentPage int `json:"current_page"`
+	TotalPages  int `json:"total_pages"`
+}
+
+type ActivityActor struct {
+	UUID      string     `json:"uuid"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	Image     string     `json:"image"`
+	TwoFactor bool       `json:"2fa_enabled"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type ActivityLog struct {
+	ID                    string          `json:"id"`
+	Batch                 string          `json:"batch"`
+	Event                 string          `json:"event"`
+	IsAPI                 bool            `json:"is_api"`
+	IP                    string          `json:"ip"`
+	Description           string          `json:"description"`
+	Properties            json.RawMessage `json:"properties"`
+	HasAdditionalMetadata bool            `json:"has_additional_metadata"`
+	Timestamp             *time.Time      `json:"timestamp"`
+	Actor                 *ActivityActor  `json:"-"`
+	Extra                 Extra           `json:"-"`
+}
+
+// UnmarshalJSON also decodes the actor included in the relationships.
+func (l *ActivityLog) UnmarshalJSON(buf []byte) error {
+	type plain ActivityLog
+	extra, err := unmarshalExtra(buf, (*plain)(l))
+	if err != nil {
+		return err
+	}
+
+	if extra.Has("relationships") {
+		var relationships struct {
+			Actor *struct {
+				Attributes *ActivityActor `json:"attributes"`
+			} `json:"actor"`
+		}
+		if extra.Decode("relationships", &relationships) == nil {
+			if relationships.Actor != nil {
+				l.Actor = relationships.Actor.Attributes
+			}
+			delete(extra, "relationships")
+		}
+	}
+	if len(extra) == 0 {
+		extra = nil
+	}
+
+	l.Extra = extra
+	return nil
+}
+
+func (l ActivityLog) MarshalJSON() ([]byte, error) {
+	type plain ActivityLog
+	return marshalExtra(plain(l), l.Extra)
+}
+
+type ActivityRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ActivityProperties holds the commonly logged properties of an activity
+// event. Anything not covered by a typed field is left in Raw.
+type ActivityProperties struct {
+	Directory   string
+	File        string
+	Files       []string
+	Renames     []ActivityRename
+	Signal      string
+	Name        string
+	Locked      bool
+	Command     string
+	Variable    string
+	Old         string
+	New         string
+	Identifier  string
+	Fingerprint string
+	Raw         map[string]json.RawMessage
+}
+
+func (l *ActivityLog) DecodeProperties() (*ActivityProperties, error) {
+	props := &ActivityProperties{Raw: map[string]json.RawMessage{}}
+	if strings.HasPrefix(l.Event, "server:power.") {
+		props.Signal = strings.TrimPrefix(l.Event, "server:power.")
+	}
+
+	// the panel serializes empty properties as an empty array
+	raw := strings.TrimSpace(string(l.Properties))
+	if raw == "" || raw == "null" || raw == "[]" {
+		return props, nil
+	}
+
+	if err := json.Unmarshal(l.Properties, &props.Raw); err != nil {
+		return nil, err
+	}
+
+	for key, value := range props.Raw {
+		switch key {
+		case "directory":
+			props.Directory = rawString(value)
+		case "file":
+			props.File = rawString(value)
+		case "files":
+			if err := json.Unmarshal(value, &props.Files); err != nil {
+				props.Files = nil
+				if err = json.Unmarshal(value, &props.Renames); err != nil {
+					return nil, err
+				}
+			}
+		case "action", "signal":
+			props.Signal = rawString(value)
+		case "name":
+			props.Name = rawString(value)
+		case "locked":
+			props.Locked, _ = strconv.ParseBool(rawString(value))
+		case "command":
+			props.Command = rawString(value)
+		case "variable":
+			props.Variable = rawString(value)
+		case "old":
+			props.Old = rawString(value)
+		case "new":
+			props.New = rawString(value)
+		case "identifier":
+			props.Identifier = rawString(value)
+		case "fingerprint":
+			props.Fingerprint = rawString(value)
+		default:
+			continue
+		}
+
+		delete(props.Raw, key)
+	}
+
+	return props, nil
+}
+
+func rawString(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+
+	return strings.Trim(string(value), `"`)
+}
+
+type ActivityQuery struct {
+	Page      int
+	PerPage   int
+	Event     string
+	Since     time.Time
+	Until     time.Time
+	Ascending bool
+}
+
+func (q ActivityQuery) values() url.Values {
+	values := url.Values{}
+	values.Set("include", "actor")
+
+	if q.Page > 0 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	if q.PerPage > 0 {
+		values.Set("per_page", strconv.Itoa(q.PerPage))
+	}
+	if q.Event != "" {
+		values.Set("filter[event]", q.Event)
+	}
+	if q.Ascending {
+		values.Set("sort", "timestamp")
+	} else {
+		values.Set("sort", "-timestamp")
+	}
+
+	return values
+}
+
+func (q ActivityQuery) matches(l *ActivityLog) bool {
+	if l.Timestamp == nil {
+		return q.Since.IsZero() && q.Until.IsZero()
+	}
+	if !q.Since.IsZero() && l.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && l.Timestamp.After(q.Until) {
+		return false
+	}
+
+	return true
+}
+
+func (c *Client) getActivity(path string, query ActivityQuery) ([]*ActivityLog, *Pagination, error) {
+	req := c.newRequest("GET", path+"?"+query.values().Encode(), nil)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var model struct {
+		Data []struct {
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"data"`
+		Meta struct {
+			Pagination Pagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, nil, err
+	}
+
+	logs := make([]*ActivityLog, 0, len(model.Data))
+	for _, d := range model.Data {
+		if len(d.Attributes) == 0 || string(d.Attributes) == "null" {
+			continue
+		}
+
+		log := &ActivityLog{}
+		if err = json.Unmarshal(d.Attributes, log); err != nil {
+			return nil, nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, &model.Meta.Pagination, nil
+}
+
+func (q ActivityQuery) filter(logs []*ActivityLog) []*ActivityLog {
+	if q.Since.IsZero() && q.Until.IsZero() {
+		return logs
+	}
+
+	filtered := make([]*ActivityLog, 0, len(logs))
+	for _, l := range logs {
+		if q.matches(l) {
+			filtered = append(filtered, l)
+		}
+	}
+
+	return filtered
+}
+
+func (c *Client) getAllActivity(path string, query ActivityQuery) ([]*ActivityLog, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	var logs []*ActivityLog
+	for {
+		page, meta, err := c.getActivity(path, query)
+		if err != nil {
+			return nil, err
+		}
+
+		logs = append(logs, query.filter(page)...)
+		if meta.CurrentPage >= meta.TotalPages || len(page) == 0 {
+			break
+		}
+
+		// entries are sorted by timestamp so there is nothing left in the
+		// range once a page ends outside of it
+		last := page[len(page)-1].Timestamp
+		if last != nil {
+			if !query.Ascending && !query.Since.IsZero() && last.Before(query.Since) {
+				break
+			}
+			if query.Ascending && !query.Until.IsZero() && last.After(query.Until) {
+				break
+			}
+		}
+
+		query.Page = meta.CurrentPage + 1
+	}
+
+	return logs, nil
+}
+
+func (c *Client) GetAccountActivity(query ActivityQuery) ([]*ActivityLog, *Pagination, error) {
+	logs, meta, err := c.getActivity("/account/activity", query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return query.filter(logs), meta, nil
+}
+
+func (c *Client) GetAllAccountActivity(query ActivityQuery) ([]*ActivityLog, error) {
+	return c.getAllActivity("/account/activity", query)
+}
+
+func (c *Client) GetServerActivity(identifier string, query ActivityQuery) ([]*ActivityLog, *Pagination, error) {
+	logs, meta, err := c.getActivity(fmt.Sprintf("/servers/%s/activity", identifier), query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return query.filter(logs), meta, nil
+}
+
+func (c *Client) GetAllServerActivity(identifier string, query ActivityQuery) ([]*ActivityLog, error) {
+	return c.getAllActivity(fmt.Sprintf("/servers/%s/activity", identifier), query)
+}
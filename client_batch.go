@@ -0,0 +1,227 @@
+package crocgodyl
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	BatchRename = "rename"
+	BatchCopy   = "copy"
+	BatchChmod  = "chmod"
+	BatchDelete = "delete"
+)
+
+type BatchResult struct {
+	Op     string
+	Path   string
+	Target string
+	Mode   uint32
+	Err    error
+}
+
+type BatchReport struct {
+	Succeeded int
+	Failed    int
+	Results   []*BatchResult
+}
+
+func (r *BatchReport) record(results ...*BatchResult) {
+	for _, res := range results {
+		r.Results = append(r.Results, res)
+		if res.Err != nil {
+			r.Failed++
+		} else {
+			r.Succeeded++
+		}
+	}
+}
+
+func (r *BatchReport) err() error {
+	if r.Failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d file operations failed", r.Failed, len(r.Results))
+}
+
+// FileBatch collects file operations on absolute paths and sends them with as
+// few requests as possible. Consecutive operations of the same kind are sent
+// together relative to the deepest directory they have in common.
+type FileBatch struct {
+	client     *Client
+	identifier string
+	ops        []*BatchResult
+}
+
+func (c *Client) NewFileBatch(identifier string) *FileBatch {
+	return &FileBatch{client: c, identifier: identifier}
+}
+
+func (b *FileBatch) add(op, file, target string, mode uint32, err error) *FileBatch {
+	res := &BatchResult{Op: op, Path: file, Target: target, Mode: mode, Err: err}
+	if err == nil {
+		res.Path, res.Err = cleanBatchPath(file)
+	}
+	if res.Err == nil && target != "" {
+		res.Target, res.Err = cleanBatchPath(target)
+	}
+
+	b.ops = append(b.ops, res)
+	return b
+}
+
+func (b *FileBatch) Rename(from, to string) *FileBatch {
+	return b.add(BatchRename, from, to, 0, nil)
+}
+
+// Copy duplicates a file next to itself, the daemon picks the name of the copy.
+func (b *FileBatch) Copy(files ...string) *FileBatch {
+	for _, f := range files {
+		b.add(BatchCopy, f, "", 0, nil)
+	}
+	return b
+}
+
+// Chmod sets the mode of a file from an octal string such as "644" or "0755".
+func (b *FileBatch) Chmod(file, mode string) *FileBatch {
+	bits, err := ParseFileMode(mode)
+	return b.add(BatchChmod, file, "", bits, err)
+}
+
+func (b *FileBatch) Delete(files ...string) *FileBatch {
+	for _, f := range files {
+		b.add(BatchDelete, f, "", 0, nil)
+	}
+	return b
+}
+
+// Execute sends the queued operations in order. Operations that failed
+// validation are reported without being sent, and a failed request marks
+// every file that was part of it as failed.
+func (b *FileBatch) Execute() (*BatchReport, error) {
+	report := &BatchReport{}
+	ops := b.ops
+	b.ops = nil
+
+	for len(ops) > 0 {
+		n := 1
+		for n < len(ops) && ops[n].Op == ops[0].Op {
+			n++
+		}
+
+		var group []*BatchResult
+		for _, op := range ops[:n] {
+			if op.Err != nil {
+				report.record(op)
+				continue
+			}
+			group = append(group, op)
+		}
+		ops = ops[n:]
+
+		if len(group) > 0 {
+			b.execute(group)
+			report.record(group...)
+		}
+	}
+
+	return report, report.err()
+}
+
+func (b *FileBatch) execute(group []*BatchResult) {
+	var paths []string
+	for _, op := range group {
+		paths = append(paths, op.Path)
+		if op.Target != "" {
+			paths = append(paths, op.Target)
+		}
+	}
+	root := commonRoot(paths)
+
+	var err error
+	switch group[0].Op {
+	case BatchRename:
+		files := RenameDescriptor{Root: root}
+		for _, op := range group {
+			files.Files = append(files.Files, &RenameFile{From: relativeTo(root, op.Path), To: relativeTo(root, op.Target)})
+		}
+		err = b.client.RenameServerFiles(b.identifier, files)
+
+	case BatchChmod:
+		files := ChmodDescriptor{Root: root}
+		for _, op := range group {
+			files.Files = append(files.Files, &ChmodFile{File: relativeTo(root, op.Path), Mode: op.Mode})
+		}
+		err = b.client.ChmodServerFiles(b.identifier, files)
+
+	case BatchDelete:
+		files := DeleteFilesDescriptor{Root: root}
+		for _, op := range group {
+			files.Files = append(files.Files, relativeTo(root, op.Path))
+		}
+		err = b.client.DeleteServerFiles(b.identifier, files)
+
+	case BatchCopy:
+		// there is no endpoint to copy several files at once
+		for _, op := range group {
+			op.Err = b.client.CopyServerFile(b.identifier, op.Path)
+		}
+		return
+	}
+
+	for _, op := range group {
+		op.Err = err
+	}
+}
+
+// ParseFileMode validates an octal permission string such as "0644" and
+// returns it in the form the panel expects, where the decimal digits are the
+// octal digits of the mode.
+func ParseFileMode(mode string) (uint32, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(mode, "0o"), "0")
+	if s == "" {
+		s = "0"
+	}
+
+	bits, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || bits > 0777 {
+		return 0, fmt.Errorf("invalid file mode %q", mode)
+	}
+
+	digits, _ := strconv.ParseUint(strconv.FormatUint(bits, 8), 10, 32)
+	return uint32(digits), nil
+}
+
+func cleanBatchPath(p string) (string, error) {
+	for _, part := range strings.Split(p, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("path %q must not contain ..", p)
+		}
+	}
+
+	clean := path.Clean("/" + p)
+	if clean == "/" {
+		return "", errors.New("the server root can not be changed")
+	}
+
+	return clean, nil
+}
+
+// commonRoot returns the deepest directory containing all of the paths.
+func commonRoot(paths []string) string {
+	root := path.Dir(paths[0])
+	for _, p := range paths[1:] {
+		for root != "/" && !strings.HasPrefix(p, root+"/") {
+			root = path.Dir(root)
+		}
+	}
+
+	return root
+}
+
+func relativeTo(root, p string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+}
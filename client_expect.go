@@ -0,0 +1,263 @@
+package crocgodyl
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrExpectTimeout = errors.New("timed out waiting for console output")
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// ConsoleSession keeps a ring buffer of recent console lines and the last
+// known server state so scripts can wait for output after sending commands.
+type ConsoleSession struct {
+	console *Console
+	cancel  func()
+	stop    chan struct{}
+	once    sync.Once
+
+	mu      sync.Mutex
+	lines   []string
+	seq     uint64
+	state   ServerState
+	waiters map[*expectWaiter]struct{}
+	notify  chan struct{}
+	done    chan struct{}
+}
+
+type expectWaiter struct {
+	pattern *regexp.Regexp
+	match   chan []string
+}
+
+func (c *Console) NewSession(bufferLines int) *ConsoleSession {
+	if bufferLines <= 0 {
+		bufferLines = 500
+	}
+
+	events, cancel := c.Subscribe(64)
+	s := &ConsoleSession{
+		console: c,
+		cancel:  cancel,
+		stop:    make(chan struct{}),
+		lines:   make([]string, bufferLines),
+		waiters: map[*expectWaiter]struct{}{},
+		notify:  make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go s.run(events)
+	return s
+}
+
+func (s *ConsoleSession) Console() *Console {
+	return s.console
+}
+
+func (s *ConsoleSession) run(events <-chan *ConsoleEvent) {
+	defer close(s.done)
+
+	for {
+		var event *ConsoleEvent
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			event = e
+		case <-s.stop:
+			return
+		}
+
+		s.mu.Lock()
+		switch event.Event {
+		case "console output":
+			// the daemon can batch several lines into a single event, colour
+			// codes are stripped so patterns match the visible text
+			text := ansiEscape.ReplaceAllString(event.Line, "")
+			for _, line := range strings.Split(strings.TrimRight(text, "\r\n"), "\n") {
+				line = strings.TrimRight(line, "\r")
+				s.lines[s.seq%uint64(len(s.lines))] = line
+				s.seq++
+
+				for w := range s.waiters {
+					if match := w.pattern.FindStringSubmatch(line); match != nil {
+						w.match <- match
+						delete(s.waiters, w)
+					}
+				}
+			}
+		case "status", "stats":
+			if event.State != "" {
+				s.state = event.State
+			}
+		default:
+			s.mu.Unlock()
+			continue
+		}
+		s.broadcast()
+		s.mu.Unlock()
+	}
+}
+
+func (s *ConsoleSession) broadcast() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// Lines returns the buffered console lines, oldest first.
+func (s *ConsoleSession) Lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.linesFrom(0)
+}
+
+func (s *ConsoleSession) linesFrom(mark uint64) []string {
+	size := uint64(len(s.lines))
+	if s.seq > size && mark < s.seq-size {
+		mark = s.seq - size
+	}
+
+	out := make([]string, 0, s.seq-mark)
+	for i := mark; i < s.seq; i++ {
+		out = append(out, s.lines[i%size])
+	}
+
+	return out
+}
+
+// Mark returns a position in the console stream. Passing it to ExpectFrom
+// only matches lines received after the mark was taken.
+func (s *ConsoleSession) Mark() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seq
+}
+
+func (s *ConsoleSession) State() ServerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// ExpectFrom waits until a line received after mark matches the pattern and
+// returns the submatches of that line.
+func (s *ConsoleSession) ExpectFrom(mark uint64, pattern *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	s.mu.Lock()
+	for _, line := range s.linesFrom(mark) {
+		if match := pattern.FindStringSubmatch(line); match != nil {
+			s.mu.Unlock()
+			return match, nil
+		}
+	}
+
+	// lines arriving from now on are matched as they come in so none are
+	// missed if the ring buffer wraps before this goroutine wakes up
+	w := &expectWaiter{pattern: pattern, match: make(chan []string, 1)}
+	s.waiters[w] = struct{}{}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case match := <-w.match:
+		return match, nil
+	case <-s.done:
+	case <-timer.C:
+	}
+
+	s.mu.Lock()
+	delete(s.waiters, w)
+	s.mu.Unlock()
+
+	select {
+	case match := <-w.match:
+		return match, nil
+	case <-s.done:
+		return nil, errors.New("console connection closed")
+	default:
+		return nil, ErrExpectTimeout
+	}
+}
+
+func (s *ConsoleSession) Expect(pattern *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	return s.ExpectFrom(s.Mark(), pattern, timeout)
+}
+
+// SendExpect sends a command and waits for its output to match the pattern.
+func (s *ConsoleSession) SendExpect(command string, pattern *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	mark := s.Mark()
+	if err := s.console.SendCommand(command); err != nil {
+		return nil, err
+	}
+
+	return s.ExpectFrom(mark, pattern, timeout)
+}
+
+func (s *ConsoleSession) WaitState(state ServerState, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		current := s.state
+		notify := s.notify
+		s.mu.Unlock()
+
+		if current == state {
+			return nil
+		}
+
+		select {
+		case <-notify:
+		case <-s.done:
+			return errors.New("console connection closed")
+		case <-timer.C:
+			return errors.New("timed out waiting for the server to be " + state.String())
+		}
+	}
+}
+
+// StartAndWait starts the server and waits for a line matching the ready
+// pattern, such as one built from the egg's startup done strings.
+func (s *ConsoleSession) StartAndWait(ready *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	mark := s.Mark()
+	if err := s.console.SetState(PowerStart); err != nil {
+		return nil, err
+	}
+
+	return s.ExpectFrom(mark, ready, timeout)
+}
+
+func (s *ConsoleSession) Close() {
+	s.once.Do(func() {
+		s.cancel()
+		close(s.stop)
+	})
+}
+
+// ReadyPattern builds a pattern from egg startup done strings. Like the daemon,
+// strings prefixed with "regex:" are used as regular expressions and anything
+// else is matched literally.
+func ReadyPattern(done ...string) (*regexp.Regexp, error) {
+	if len(done) == 0 {
+		return nil, errors.New("at least one startup done string is required")
+	}
+
+	parts := make([]string, 0, len(done))
+	for _, d := range done {
+		if strings.HasPrefix(d, "regex:") {
+			parts = append(parts, "(?:"+strings.TrimPrefix(d, "regex:")+")")
+		} else {
+			parts = append(parts, regexp.QuoteMeta(d))
+		}
+	}
+
+	return regexp.Compile(strings.Join(parts, "|"))
+}
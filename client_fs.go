@@ -0,0 +1,279 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ServerFS exposes the files of a server through the io/fs interfaces so it
+// can be used with fs.WalkDir, fs.Glob, template.ParseFS or http.FS.
+type ServerFS struct {
+	client     *Client
+	identifier string
+}
+
+var (
+	_ fs.FS         = (*ServerFS)(nil)
+	_ fs.ReadDirFS  = (*ServerFS)(nil)
+	_ fs.StatFS     = (*ServerFS)(nil)
+	_ fs.ReadFileFS = (*ServerFS)(nil)
+)
+
+func (c *Client) ServerFS(identifier string) *ServerFS {
+	return &ServerFS{client: c, identifier: identifier}
+}
+
+func (f *ServerFS) Client() *Client {
+	return f.client
+}
+
+func remotePath(name string) string {
+	if name == "." {
+		return "/"
+	}
+	return "/" + name
+}
+
+func (f *ServerFS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &serverDir{fs: f, name: name, info: info}, nil
+	}
+
+	buf, err := f.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serverFile{Reader: bytes.NewReader(buf), info: info}, nil
+}
+
+func (f *ServerFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	files, err := f.client.GetServerFiles(f.identifier, remotePath(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fsError(err)}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, fileInfo{file})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+func (f *ServerFS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+func (f *ServerFS) stat(op, name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	// the root directory is never listed so it has to be made up
+	if name == "." {
+		return fileInfo{&File{Name: ".", Mode: "drwxr-xr-x", ModeBits: "755"}}, nil
+	}
+
+	file, err := f.client.StatServerFile(f.identifier, remotePath(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fsError(err)}
+	}
+
+	return fileInfo{file}, nil
+}
+
+func (f *ServerFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+
+	buf, err := f.client.GetServerFileContents(f.identifier, remotePath(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fsError(err)}
+	}
+
+	return buf, nil
+}
+
+func fsError(err error) error {
+	if IsNotFound(err) || isMissingFile(err) {
+		return fs.ErrNotExist
+	}
+
+	var errs *ApiError
+	if errors.As(err, &errs) {
+		for _, e := range errs.Errors {
+			if e.Status == "403" {
+				return fs.ErrPermission
+			}
+		}
+	}
+
+	return err
+}
+
+// FileMode converts the permission bits and type reported by the panel into
+// an fs.FileMode. Symlinks are reported as such without the directory bit so
+// walking the tree does not follow them.
+func (f *File) FileMode() fs.FileMode {
+	var mode fs.FileMode
+	if bits, err := strconv.ParseUint(f.ModeBits, 8, 32); err == nil {
+		mode = fs.FileMode(bits) & fs.ModePerm
+	} else {
+		mode = parseModeString(f.Mode)
+	}
+
+	switch {
+	case f.IsSymlink:
+		mode |= fs.ModeSymlink
+	case !f.IsFile:
+		mode |= fs.ModeDir
+	}
+
+	return mode
+}
+
+func parseModeString(s string) fs.FileMode {
+	// skip the type character of strings such as "drwxr-xr-x"
+	if len(s) == 10 {
+		s = s[1:]
+	}
+	if len(s) != 9 {
+		return 0
+	}
+
+	var mode fs.FileMode
+	for i, c := range s {
+		if c != '-' {
+			mode |= 1 << uint(8-i)
+		}
+	}
+
+	return mode
+}
+
+func (f *File) IsDir() bool {
+	return !f.IsFile && !f.IsSymlink
+}
+
+type fileInfo struct {
+	file *File
+}
+
+func (i fileInfo) Name() string {
+	return i.file.Name
+}
+
+func (i fileInfo) Size() int64 {
+	return i.file.Size
+}
+
+func (i fileInfo) Mode() fs.FileMode {
+	return i.file.FileMode()
+}
+
+func (i fileInfo) ModTime() time.Time {
+	if i.file.ModifiedAt != nil {
+		return *i.file.ModifiedAt
+	}
+	if i.file.CreatedAt != nil {
+		return *i.file.CreatedAt
+	}
+	return time.Time{}
+}
+
+func (i fileInfo) IsDir() bool {
+	return i.Mode().IsDir()
+}
+
+func (i fileInfo) Sys() interface{} {
+	return i.file
+}
+
+func (i fileInfo) Type() fs.FileMode {
+	return i.Mode().Type()
+}
+
+func (i fileInfo) Info() (fs.FileInfo, error) {
+	return i, nil
+}
+
+type serverFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *serverFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *serverFile) Close() error {
+	return nil
+}
+
+type serverDir struct {
+	fs      *ServerFS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *serverDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *serverDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *serverDir) Close() error {
+	return nil
+}
+
+func (d *serverDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+
+	return entries, nil
+}
@@ -0,0 +1,208 @@
+package crocgodyl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrFileTooLarge = errors.New("file is larger than the allowed size")
+
+// tailChunk is how much of the end of a file is fetched at first when tailing
+// it, it grows until enough lines have been read.
+const tailChunk = 64 * 1024
+
+// isTooLarge reports whether the panel refused to return file contents
+// because the file is over its size limit.
+func isTooLarge(err error) bool {
+	var errs *ApiError
+	if !errors.As(err, &errs) {
+		return false
+	}
+
+	for _, e := range errs.Errors {
+		if e.Status == "413" || e.Code == "FileSizeTooLargeException" || strings.Contains(strings.ToLower(e.Detail), "too large") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenServerFile streams the contents of a file instead of reading it into
+// memory. Files the panel refuses to return because of their size are read
+// from a signed download URL instead.
+func (c *Client) OpenServerFile(identifier, file string) (io.ReadCloser, error) {
+	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/files/contents?file=%s", identifier, url.PathEscape(file)), nil)
+	req.Header.Set("Accept", "application/json,text/plain")
+
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusOK {
+		return res.Body, nil
+	}
+
+	if _, err = validate(res); err == nil {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if !isTooLarge(err) {
+		return nil, err
+	}
+
+	dl, err := c.DownloadServerFile(identifier, file)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = dl.open(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Body, nil
+}
+
+// ReadServerFile reads the contents of a file, failing with ErrFileTooLarge
+// instead of reading more than limit bytes. A limit of zero or less reads the
+// whole file.
+func (c *Client) ReadServerFile(identifier, file string, limit int64) ([]byte, error) {
+	r, err := c.OpenServerFile(identifier, file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(buf)) > limit {
+		return nil, ErrFileTooLarge
+	}
+
+	return buf, nil
+}
+
+// HeadServerFile returns up to the first n lines of a file, only reading as
+// much of it as needed.
+func (c *Client) HeadServerFile(identifier, file string, n int) ([]string, error) {
+	if err := checkLineCount(n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return []string{}, nil
+	}
+
+	r, err := c.OpenServerFile(identifier, file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for len(lines) < n && scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+
+	return lines, scanner.Err()
+}
+
+// TailServerFile returns up to the last n lines of a file such as
+// logs/latest.log. Large files are read from the end of their download URL so
+// only the last part of the file is transferred.
+func (c *Client) TailServerFile(identifier, file string, n int) ([]string, error) {
+	if err := checkLineCount(n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return []string{}, nil
+	}
+
+	info, err := c.StatServerFile(identifier, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Size <= tailChunk {
+		buf, err := c.ReadServerFile(identifier, file, 0)
+		if err != nil {
+			return nil, err
+		}
+		return lastLines(buf, n, false), nil
+	}
+
+	dl, err := c.DownloadServerFile(identifier, file)
+	if err != nil {
+		return nil, err
+	}
+
+	for chunk := int64(tailChunk); ; chunk *= 4 {
+		offset := info.Size - chunk
+		if offset < 0 {
+			offset = 0
+		}
+
+		res, err := dl.open(offset)
+		if err != nil {
+			return nil, err
+		}
+		buf, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// a server that ignores the range sends the whole file
+		partial := offset > 0 && res.StatusCode == http.StatusPartialContent
+		lines := lastLines(buf, n, partial)
+		if len(lines) >= n || !partial {
+			return lines, nil
+		}
+	}
+}
+
+// checkLineCount rejects a negative number of lines, zero lines is valid and
+// returns nothing without reading the file.
+func checkLineCount(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid line count %d", n)
+	}
+	return nil
+}
+
+// lastLines returns up to the last n lines of buf, dropping the first line
+// when buf starts in the middle of the file.
+func lastLines(buf []byte, n int, partial bool) []string {
+	text := strings.TrimRight(string(buf), "\r\n")
+	if text == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(text, "\n")
+	if partial {
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+
+	return lines
+}
@@ -0,0 +1,246 @@
+package crocgodyl
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileSearch describes which server files to look for. Every field that is
+// set has to match, an empty search matches every file.
+type FileSearch struct {
+	Root           string
+	Name           string
+	Pattern        *regexp.Regexp
+	MinSize        int64
+	MaxSize        int64
+	MimeTypes      []string
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+	MaxDepth       int
+	IncludeDirs    bool
+	Grep           *regexp.Regexp
+	MaxGrepSize    int64
+	Concurrency    int
+}
+
+type LineMatch struct {
+	Line int
+	Text string
+}
+
+type FileMatch struct {
+	Path  string
+	File  *File
+	Lines []*LineMatch
+}
+
+func (s FileSearch) match(p string, f *File) bool {
+	if !f.IsFile && !s.IncludeDirs {
+		return false
+	}
+	if s.Name != "" {
+		if ok, _ := path.Match(s.Name, f.Name); !ok {
+			return false
+		}
+	}
+	if s.Pattern != nil && !s.Pattern.MatchString(p) {
+		return false
+	}
+	if s.MinSize > 0 && f.Size < s.MinSize {
+		return false
+	}
+	if s.MaxSize > 0 && f.Size > s.MaxSize {
+		return false
+	}
+
+	if len(s.MimeTypes) > 0 {
+		ok := false
+		for _, m := range s.MimeTypes {
+			if strings.HasPrefix(f.MimeType, m) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	modified := fileInfo{f}.ModTime()
+	if !s.ModifiedAfter.IsZero() && !modified.After(s.ModifiedAfter) {
+		return false
+	}
+	if !s.ModifiedBefore.IsZero() && !modified.Before(s.ModifiedBefore) {
+		return false
+	}
+
+	return true
+}
+
+// isTextMime reports whether the daemon detected a file as text, only those
+// files are fetched when searching their contents.
+func isTextMime(mime string) bool {
+	if strings.HasPrefix(mime, "text/") || strings.HasPrefix(mime, "inode/x-empty") {
+		return true
+	}
+
+	for _, t := range []string{"json", "xml", "yaml", "toml", "javascript", "x-sh", "x-php", "x-python"} {
+		if strings.HasPrefix(mime, "application/") && strings.Contains(mime, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// walkServerFiles lists a directory tree using up to concurrency requests at a
+// time. The callback is called for every entry and can be called from several
+// goroutines at once, symlinked directories are not followed.
+func (c *Client) walkServerFiles(identifier, root string, maxDepth, concurrency int, fn func(p string, f *File)) error {
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		first error
+		sem   = make(chan struct{}, concurrency)
+	)
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		defer wg.Done()
+
+		sem <- struct{}{}
+		files, err := c.GetServerFiles(identifier, dir)
+		<-sem
+
+		if err != nil {
+			mu.Lock()
+			if first == nil {
+				first = err
+			}
+			mu.Unlock()
+			return
+		}
+
+		for _, f := range files {
+			p := path.Join(dir, f.Name)
+			fn(p, f)
+
+			if f.IsDir() && (maxDepth <= 0 || depth < maxDepth) {
+				wg.Add(1)
+				go walk(p, depth+1)
+			}
+		}
+	}
+
+	wg.Add(1)
+	walk(path.Clean("/"+root), 1)
+	wg.Wait()
+
+	return first
+}
+
+// SearchServerFiles walks the server's files below the search root and returns
+// the matches sorted by path. When Grep is set only text files whose contents
+// match are returned, along with the matching lines. Directories that can not
+// be listed and files that can not be read are skipped, the first error is
+// returned along with the matches that were found.
+func (c *Client) SearchServerFiles(identifier string, search FileSearch) ([]*FileMatch, error) {
+	var (
+		mu      sync.Mutex
+		matches []*FileMatch
+	)
+
+	err := c.walkServerFiles(identifier, search.Root, search.MaxDepth, search.Concurrency, func(p string, f *File) {
+		if !search.match(p, f) {
+			return
+		}
+
+		mu.Lock()
+		matches = append(matches, &FileMatch{Path: p, File: f})
+		mu.Unlock()
+	})
+
+	if search.Grep != nil {
+		matches, err = c.grepServerFiles(identifier, matches, search, err)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Path < matches[j].Path
+	})
+
+	return matches, err
+}
+
+func (c *Client) grepServerFiles(identifier string, files []*FileMatch, search FileSearch, first error) ([]*FileMatch, error) {
+	concurrency := search.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		matches []*FileMatch
+		sem     = make(chan struct{}, concurrency)
+	)
+
+	for _, m := range files {
+		if !m.File.IsFile || !isTextMime(m.File.MimeType) {
+			continue
+		}
+		if search.MaxGrepSize > 0 && m.File.Size > search.MaxGrepSize {
+			continue
+		}
+
+		wg.Add(1)
+		go func(m *FileMatch) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			buf, err := c.GetServerFileContents(identifier, m.Path)
+			<-sem
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if first == nil {
+					first = err
+				}
+				return
+			}
+
+			m.Lines = grepLines(buf, search.Grep)
+			if len(m.Lines) > 0 {
+				matches = append(matches, m)
+			}
+		}(m)
+	}
+	wg.Wait()
+
+	return matches, first
+}
+
+func grepLines(buf []byte, pattern *regexp.Regexp) []*LineMatch {
+	var lines []*LineMatch
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	scanner.Buffer(make([]byte, 64*1024), len(buf)+1)
+	for n := 1; scanner.Scan(); n++ {
+		if line := scanner.Text(); pattern.MatchString(line) {
+			lines = append(lines, &LineMatch{Line: n, Text: strings.TrimRight(line, "\r")})
+		}
+	}
+
+	return lines
+}
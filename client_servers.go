@@ -2,14 +2,21 @@ package crocgodyl
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -46,10 +53,23 @@ type ClientServer struct {
 	DockerImage   string        `json:"docker_image"`
 	EggFeatures   []string      `json:"egg_features"`
 	FeatureLimits FeatureLimits `json:"feature_limits"`
-	Status        string        `json:"status"`
+	Status        ServerStatus  `json:"status"`
 	Suspended     bool          `json:"is_suspended"`
 	Installing    bool          `json:"is_installing"`
 	Transferring  bool          `json:"is_transferring"`
+	Extra         Extra         `json:"-"`
+}
+
+func (s *ClientServer) UnmarshalJSON(buf []byte) error {
+	type plain ClientServer
+	extra, err := unmarshalExtra(buf, (*plain)(s))
+	s.Extra = extra
+	return err
+}
+
+func (s ClientServer) MarshalJSON() ([]byte, error) {
+	type plain ClientServer
+	return marshalExtra(plain(s), s.Extra)
 }
 
 func (c *Client) GetServers() ([]*ClientServer, error) {
@@ -140,9 +160,22 @@ type ResourceUsage struct {
 }
 
 type Resources struct {
-	State     string        `json:"current_state,omitempty"`
+	State     ServerState   `json:"current_state,omitempty"`
 	Suspended bool          `json:"is_suspended"`
 	Usage     ResourceUsage `json:"resources"`
+	Extra     Extra         `json:"-"`
+}
+
+func (r *Resources) UnmarshalJSON(buf []byte) error {
+	type plain Resources
+	extra, err := unmarshalExtra(buf, (*plain)(r))
+	r.Extra = extra
+	return err
+}
+
+func (r Resources) MarshalJSON() ([]byte, error) {
+	type plain Resources
+	return marshalExtra(plain(r), r.Extra)
 }
 
 func (c *Client) GetServerResources(identifier string) (*Resources, error) {
@@ -182,8 +215,12 @@ func (c *Client) SendServerCommand(identifier, command string) error {
 	return err
 }
 
-func (c *Client) SetServerPowerState(identifier, state string) error {
-	data, _ := json.Marshal(map[string]string{"signal": state})
+func (c *Client) SetServerPowerState(identifier string, signal PowerSignal) error {
+	if !signal.Valid() {
+		return fmt.Errorf("unknown power signal %q", string(signal))
+	}
+
+	data, _ := json.Marshal(map[string]PowerSignal{"signal": signal})
 	body := bytes.Buffer{}
 	body.Write(data)
 
@@ -197,6 +234,118 @@ func (c *Client) SetServerPowerState(identifier, state string) error {
 	return err
 }
 
+func (c *Client) RenameServer(identifier, name, description string) error {
+	if name == "" {
+		return errors.New("a server name is required")
+	}
+
+	data, _ := json.Marshal(map[string]string{"name": name, "description": description})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := c.newRequest("POST", fmt.Sprintf("/servers/%s/settings/rename", identifier), &body)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (c *Client) ReinstallServer(identifier string) error {
+	req := c.newRequest("POST", fmt.Sprintf("/servers/%s/settings/reinstall", identifier), nil)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
+func (c *Client) ReinstallServerAndWait(identifier string, interval, timeout time.Duration) (*ClientServer, error) {
+	if err := c.ReinstallServer(identifier); err != nil {
+		return nil, err
+	}
+
+	return c.WaitServerInstalled(identifier, interval, timeout)
+}
+
+// WaitServerInstalled polls the server until it is no longer installing. The
+// panel can take a moment to flag the server after a reinstall is requested,
+// so a server that is never seen installing is only considered done after a
+// couple of polls. A zero timeout waits indefinitely.
+func (c *Client) WaitServerInstalled(identifier string, interval, timeout time.Duration) (*ClientServer, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	start := time.Now()
+	seen := false
+	for {
+		server, err := c.GetServer(identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		if server.Installing {
+			seen = true
+		} else if seen || time.Since(start) >= 2*interval {
+			return server, nil
+		}
+
+		if timeout > 0 && time.Since(start) >= timeout {
+			return server, errors.New("timed out waiting for the server to finish installing")
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+func (c *Client) GetServerDockerImages(identifier string) (map[string]string, error) {
+	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/startup", identifier), nil)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Meta struct {
+			DockerImages map[string]string `json:"docker_images"`
+		} `json:"meta"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return model.Meta.DockerImages, nil
+}
+
+func (c *Client) SetServerDockerImage(identifier, image string) error {
+	if image == "" {
+		return errors.New("a docker image is required")
+	}
+
+	data, _ := json.Marshal(map[string]string{"docker_image": image})
+	body := bytes.Buffer{}
+	body.Write(data)
+
+	req := c.newRequest("PUT", fmt.Sprintf("/servers/%s/settings/docker-image", identifier), &body)
+	res, err := c.Http.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = validate(res)
+	return err
+}
+
 type ClientDatabase struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
@@ -207,6 +356,19 @@ type ClientDatabase struct {
 	} `json:"host"`
 	ConnectionsFrom string `json:"connections_from"`
 	MaxConnections  int    `json:"max_connections"`
+	Extra           Extra  `json:"-"`
+}
+
+func (d *ClientDatabase) UnmarshalJSON(buf []byte) error {
+	type plain ClientDatabase
+	extra, err := unmarshalExtra(buf, (*plain)(d))
+	d.Extra = extra
+	return err
+}
+
+func (d ClientDatabase) MarshalJSON() ([]byte, error) {
+	type plain ClientDatabase
+	return marshalExtra(plain(d), d.Extra)
 }
 
 func (c *Client) GetServerDatabases(identifier string) ([]*ClientDatabase, error) {
@@ -307,6 +469,19 @@ type File struct {
 	MimeType   string     `json:"mimetype"`
 	CreatedAt  *time.Time `json:"created_at"`
 	ModifiedAt *time.Time `json:"modified_at,omitempty"`
+	Extra      Extra      `json:"-"`
+}
+
+func (f *File) UnmarshalJSON(buf []byte) error {
+	type plain File
+	extra, err := unmarshalExtra(buf, (*plain)(f))
+	f.Extra = extra
+	return err
+}
+
+func (f File) MarshalJSON() ([]byte, error) {
+	type plain File
+	return marshalExtra(plain(f), f.Extra)
 }
 
 func (c *Client) GetServerFiles(identififer, root string) ([]*File, error) {
@@ -338,6 +513,63 @@ func (c *Client) GetServerFiles(identififer, root string) ([]*File, error) {
 	return files, nil
 }
 
+// StatServerFile returns the attributes of a single file by listing the
+// directory it is in. Symlinks are returned as they are listed and not
+// followed, so IsFile is false for them regardless of what they point to.
+func (c *Client) StatServerFile(identifier, file string) (*File, error) {
+	dir, name := path.Split(path.Clean("/" + file))
+	if name == "" {
+		// the root directory never shows up in a listing
+		return &File{Name: "/", Mode: "drwxr-xr-x", ModeBits: "755", MimeType: "inode/directory"}, nil
+	}
+
+	files, err := c.GetServerFiles(identifier, dir)
+	if err != nil {
+		if isMissingFile(err) {
+			return nil, &FileNotFoundError{Path: path.Join(dir, name)}
+		}
+		return nil, err
+	}
+
+	for _, f := range files {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+
+	return nil, &FileNotFoundError{Path: path.Join(dir, name)}
+}
+
+func (c *Client) ServerFileExists(identifier, file string) (bool, error) {
+	_, err := c.StatServerFile(identifier, file)
+	if err != nil {
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListServerFilesRecursive lists every file and directory below root, keyed by
+// their absolute path. Symlinked directories are listed but not followed.
+func (c *Client) ListServerFilesRecursive(identifier, root string) (map[string]*File, error) {
+	var mu sync.Mutex
+	files := map[string]*File{}
+
+	err := c.walkServerFiles(identifier, root, 0, 0, func(p string, f *File) {
+		mu.Lock()
+		files[p] = f
+		mu.Unlock()
+	})
+	if err != nil && isMissingFile(err) {
+		return nil, &FileNotFoundError{Path: path.Clean("/" + root)}
+	}
+
+	return files, err
+}
+
 func (c *Client) GetServerFileContents(identifier, file string) ([]byte, error) {
 	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/files/contents?file=%s", identifier, url.PathEscape(file)), nil)
 	req.Header.Set("Accept", "application/json,text/plain")
@@ -351,10 +583,17 @@ func (c *Client) GetServerFileContents(identifier, file string) ([]byte, error)
 }
 
 type Downloader struct {
-	client *Client
-	Name   string
-	Path   string
-	url    string
+	client     *Client
+	identifier string
+	url        string
+	used       bool
+	Name       string
+	Path       string
+	Size       int64
+	Resume     bool
+	Overwrite  bool
+	Hash       hash.Hash
+	Progress   func(written, total int64)
 }
 
 func (d *Downloader) Client() *Client {
@@ -365,51 +604,212 @@ func (d *Downloader) URL() string {
 	return d.url
 }
 
+// Execute saves the file to its name in the working directory. An existing
+// file is only replaced with Overwrite set, or continued with Resume set.
 func (d *Downloader) Execute() error {
-	info, err := os.Stat(d.Path)
-	if err == nil {
-		if !info.IsDir() {
-			return errors.New("refusing to overwrite existing file path")
+	if !d.Overwrite && !d.Resume {
+		if _, err := os.Lstat(d.Name); err == nil {
+			return fmt.Errorf("%s already exists", d.Name)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return d.SaveTo(d.Name)
+}
+
+// Checksum returns the hex encoded sum of the downloaded content if a hash
+// was set before the download.
+func (d *Downloader) Checksum() string {
+	if d.Hash == nil {
+		return ""
+	}
+	return hex.EncodeToString(d.Hash.Sum(nil))
+}
+
+// open requests the file from the daemon starting at offset. Download links
+// can only be used once so a new one is requested for every attempt after
+// the first.
+func (d *Downloader) open(offset int64) (*http.Response, error) {
+	if d.used {
+		next, err := d.client.DownloadServerFile(d.identifier, d.Path)
+		if err != nil {
+			return nil, err
+		}
+		d.url = next.url
+	}
+	d.used = true
+
+	req, err := http.NewRequest("GET", d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", agent(d.client.userAgent))
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := d.client.Http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		res.Body.Close()
+		return nil, fmt.Errorf("recieved an unexpected response: %s", res.Status)
+	}
+
+	return res, nil
+}
+
+func (d *Downloader) copy(w io.Writer, res *http.Response, offset int64) (int64, error) {
+	defer res.Body.Close()
+
+	total := d.Size
+	if res.ContentLength >= 0 {
+		total = offset + res.ContentLength
+	}
+
+	if d.Hash != nil {
+		w = io.MultiWriter(w, d.Hash)
+	}
+
+	if d.Progress != nil {
+		written := offset
+		d.Progress(written, total)
+		w = &progressWriter{w: w, fn: func(n int64) {
+			written += n
+			d.Progress(written, total)
+		}}
+	}
+
+	n, err := io.Copy(w, res.Body)
+	if err != nil {
+		return n, err
+	}
+
+	if total >= 0 && offset+n != total {
+		return n, fmt.Errorf("download incomplete: received %d of %d bytes", offset+n, total)
+	}
+
+	return n, nil
+}
+
+// WriteTo streams the whole file into w.
+func (d *Downloader) WriteTo(w io.Writer) (int64, error) {
+	if d.Hash != nil {
+		d.Hash.Reset()
+	}
+
+	res, err := d.open(0)
+	if err != nil {
+		return 0, err
+	}
+
+	return d.copy(w, res, 0)
+}
+
+// SaveTo downloads the file to the given path, or into it if the path is a
+// directory, replacing any file already there. With Resume set a partially
+// downloaded file is continued from where it ended if the daemon supports
+// range requests, and a file of the full size is kept as it is. A file larger
+// than the remote one is downloaded again.
+func (d *Downloader) SaveTo(dest string) error {
+	if info, err := os.Stat(dest); err == nil && info.IsDir() {
+		dest = filepath.Join(dest, d.Name)
+	}
+
+	var offset int64
+	if d.Resume {
+		if info, err := os.Stat(dest); err == nil && !info.IsDir() {
+			if info.Size() == d.Size {
+				return d.complete(dest)
+			}
+			offset = info.Size()
 		}
 	}
 
-	res, err := d.client.Http.Get(d.URL())
+	if d.Size >= 0 && offset > d.Size {
+		offset = 0
+	}
+
+	res, err := d.open(offset)
 	if err != nil {
 		return err
 	}
 
-	if res.StatusCode != 200 {
-		return fmt.Errorf("recieved an unexpected response: %s", res.Status)
+	// the daemon ignored the range so the file is sent from the start
+	if res.StatusCode != http.StatusPartialContent {
+		offset = 0
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_RDWR | os.O_APPEND
 	}
 
-	file, err := os.OpenFile(d.Name, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o644)
+	file, err := os.OpenFile(dest, flags, 0o644)
 	if err != nil {
+		res.Body.Close()
 		return err
 	}
 
-	defer res.Body.Close()
-	io.Copy(file, res.Body)
+	if d.Hash != nil {
+		d.Hash.Reset()
+		if offset > 0 {
+			if _, err = io.Copy(d.Hash, io.NewSectionReader(file, 0, offset)); err != nil {
+				res.Body.Close()
+				file.Close()
+				return err
+			}
+		}
+	}
+
+	_, err = d.copy(file, res, offset)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// complete finishes a resumed download whose file already has the full size
+// without requesting it again.
+func (d *Downloader) complete(dest string) error {
+	if d.Hash != nil {
+		file, err := os.Open(dest)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		d.Hash.Reset()
+		if _, err = io.Copy(d.Hash, file); err != nil {
+			return err
+		}
+	}
+
+	if d.Progress != nil {
+		d.Progress(d.Size, d.Size)
+	}
+
 	return nil
 }
 
 func (c *Client) DownloadServerFile(identifier, file string) (*Downloader, error) {
-	files, err := c.GetServerFiles(identifier, "/")
+	remote, _ := url.PathUnescape(file)
+	info, err := c.StatServerFile(identifier, remote)
 	if err != nil {
 		return nil, err
 	}
 
-	_, name := filepath.Split(file)
-	for _, f := range files {
-		if f.Name == name {
-			if f.MimeType == "inode/directory" {
-				return nil, errors.New("cannot download a directory")
-			}
-
-			break
-		}
+	// symlinks are not regular files, but only those pointing at directories
+	// are listed with the directory mime type
+	if info.MimeType == "inode/directory" || !info.IsFile && !info.IsSymlink {
+		return nil, errors.New("cannot download a directory")
 	}
 
-	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/files/download?file=%s", identifier, url.PathEscape(file)), nil)
+	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/files/download?file=%s", identifier, url.PathEscape(remote)), nil)
 	res, err := c.Http.Do(req)
 	if err != nil {
 		return nil, err
@@ -429,23 +829,26 @@ func (c *Client) DownloadServerFile(identifier, file string) (*Downloader, error
 		return nil, err
 	}
 
-	path, _ := url.PathUnescape(file)
 	dl := &Downloader{
-		client: c,
-		Name:   name,
-		Path:   path,
-		url:    model.Attributes.URL,
+		client:     c,
+		identifier: identifier,
+		Name:       info.Name,
+		Path:       remote,
+		Size:       info.Size,
+		url:        model.Attributes.URL,
 	}
 
 	return dl, nil
 }
 
+type RenameFile struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
 type RenameDescriptor struct {
-	Root  string `json:"root"`
-	Files []struct {
-		From string `json:"from"`
-		To   string `json:"to"`
-	} `json:"files"`
+	Root  string        `json:"root"`
+	Files []*RenameFile `json:"files"`
 }
 
 func (c *Client) RenameServerFiles(identifier string, files RenameDescriptor) error {
@@ -502,7 +905,7 @@ type CompressDescriptor struct {
 	Files []string `json:"files"`
 }
 
-func (c *Client) CompressServerFiles(identifier string, files CompressDescriptor) error {
+func (c *Client) CompressServerFiles(identifier string, files CompressDescriptor) (*File, error) {
 	data, _ := json.Marshal(files)
 	body := bytes.Buffer{}
 	body.Write(data)
@@ -510,11 +913,65 @@ func (c *Client) CompressServerFiles(identifier string, files CompressDescriptor
 	req := c.newRequest("POST", fmt.Sprintf("/servers/%s/files/compress", identifier), &body)
 	res, err := c.Http.Do(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = validate(res)
-	return err
+	buf, err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var model struct {
+		Attributes *File `json:"attributes"`
+	}
+	if err = json.Unmarshal(buf, &model); err != nil {
+		return nil, err
+	}
+
+	return model.Attributes, nil
+}
+
+// CompressServerFilesAndWait compresses the files and waits for the archive
+// to be fully written, which matters for large archives on slow disks.
+func (c *Client) CompressServerFilesAndWait(identifier string, files CompressDescriptor, interval, timeout time.Duration) (*File, error) {
+	archive, err := c.CompressServerFiles(identifier, files)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.WaitServerFile(identifier, path.Join("/", files.Root, archive.Name), interval, timeout)
+}
+
+// WaitServerFile polls the listing of the file's directory until the file
+// exists and its size has not changed between two polls. A zero timeout waits
+// indefinitely.
+func (c *Client) WaitServerFile(identifier, file string, interval, timeout time.Duration) (*File, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	start := time.Now()
+	var last *File
+	for {
+		current, err := c.StatServerFile(identifier, file)
+		if err != nil && !IsNotFound(err) {
+			return nil, err
+		}
+
+		if current != nil && last != nil && current.Size == last.Size {
+			return current, nil
+		}
+		last = current
+
+		if timeout > 0 && time.Since(start) >= timeout {
+			if current == nil {
+				return nil, errors.New("timed out waiting for the file to exist")
+			}
+			return current, errors.New("timed out waiting for the file size to settle")
+		}
+
+		time.Sleep(interval)
+	}
 }
 
 type DecompressDescriptor struct {
@@ -537,6 +994,106 @@ func (c *Client) DecompressServerFile(identifier string, file DecompressDescript
 	return err
 }
 
+// DecompressServerFileAndWait decompresses the archive and waits for the
+// extracted entries to stop changing. It returns the entries of the root
+// directory that were added or modified. An archive that changes nothing,
+// such as an empty one, returns no entries once the listing has stayed the
+// same for unchangedPolls polls. A zero timeout waits indefinitely.
+func (c *Client) DecompressServerFileAndWait(identifier string, file DecompressDescriptor, interval, timeout time.Duration) ([]*File, error) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	root := path.Clean("/" + file.Root)
+
+	before, err := c.GetServerFiles(identifier, root)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[string]string{}
+	for _, f := range before {
+		previous[f.Name] = fileVersion(f)
+	}
+
+	if err = c.DecompressServerFile(identifier, file); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	last := ""
+	for unchanged := 0; ; unchanged++ {
+		changed, state, err := c.extractedFiles(identifier, root, previous)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(changed) > 0 && state == last {
+			return changed, nil
+		}
+		if len(changed) > 0 {
+			unchanged = 0
+		} else if unchanged >= unchangedPolls {
+			return changed, nil
+		}
+		last = state
+
+		if timeout > 0 && time.Since(start) >= timeout {
+			if len(changed) == 0 {
+				return nil, errors.New("timed out waiting for the archive to be extracted")
+			}
+			return changed, errors.New("timed out waiting for the extracted files to settle")
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// unchangedPolls is how many polls the listing has to stay the same before an
+// archive is taken to have extracted nothing new.
+const unchangedPolls = 3
+
+// extractedFiles returns the entries of root that differ from the previous
+// listing, and a summary of them and everything below them that changes while
+// files are still being written.
+func (c *Client) extractedFiles(identifier, root string, previous map[string]string) ([]*File, string, error) {
+	files, err := c.GetServerFiles(identifier, root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var changed []*File
+	var state []string
+	for _, f := range files {
+		version := fileVersion(f)
+		if previous[f.Name] == version {
+			continue
+		}
+		changed = append(changed, f)
+		state = append(state, f.Name+"\x00"+version)
+
+		if f.IsFile || f.IsSymlink {
+			continue
+		}
+		below, err := c.ListServerFilesRecursive(identifier, path.Join(root, f.Name))
+		if err != nil {
+			return nil, "", err
+		}
+		for p, child := range below {
+			state = append(state, p+"\x00"+fileVersion(child))
+		}
+	}
+	sort.Strings(state)
+
+	return changed, strings.Join(state, "\n"), nil
+}
+
+func fileVersion(f *File) string {
+	modified := ""
+	if f.ModifiedAt != nil {
+		modified = f.ModifiedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%d %s", f.Size, modified)
+}
+
 type DeleteFilesDescriptor struct {
 	Root  string   `json:"root"`
 	Files []string `json:"files"`
@@ -577,12 +1134,16 @@ func (c *Client) CreateServerFileFolder(identifier string, file CreateFolderDesc
 	return err
 }
 
+// ChmodFile sets the mode of a file. The daemon reads the digits of the mode as
+// octal, so 0755 has to be sent as 755, see ParseFileMode.
+type ChmodFile struct {
+	File string `json:"file"`
+	Mode uint32 `json:"mode"`
+}
+
 type ChmodDescriptor struct {
-	Root  string `json:"root"`
-	Files []struct {
-		File string `json:"file"`
-		Mode uint32 `json:"mode"`
-	} `json:"files"`
+	Root  string       `json:"root"`
+	Files []*ChmodFile `json:"files"`
 }
 
 func (c *Client) ChmodServerFiles(identifier string, files ChmodDescriptor) error {
@@ -608,6 +1169,35 @@ type PullDescriptor struct {
 	Foreground bool   `json:"foreground,omitempty"`
 }
 
+// Validate checks the URL and target of a pull before it is sent to the panel,
+// which otherwise accepts the request and fails in the background.
+func (p *PullDescriptor) Validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return fmt.Errorf("invalid pull url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("pull url must use http or https")
+	}
+	if u.Host == "" {
+		return errors.New("pull url must have a host")
+	}
+
+	if p.Filename != "" {
+		if p.Filename == "." || p.Filename == ".." || strings.ContainsAny(p.Filename, "/\\\x00") {
+			return fmt.Errorf("invalid pull filename %q", p.Filename)
+		}
+	}
+
+	for _, part := range strings.Split(p.Directory, "/") {
+		if part == ".." {
+			return fmt.Errorf("invalid pull directory %q", p.Directory)
+		}
+	}
+
+	return nil
+}
+
 func (c *Client) PullServerFile(identifier string, file PullDescriptor) error {
 	data, _ := json.Marshal(file)
 	body := bytes.Buffer{}
@@ -623,10 +1213,47 @@ func (c *Client) PullServerFile(identifier string, file PullDescriptor) error {
 	return err
 }
 
+// PullServerFileAndWait validates the pull, sends it in the background and
+// waits for the downloaded file to be complete. Without a filename the name is
+// taken from the last element of the URL path, so it can not be combined with
+// UseHeader.
+func (c *Client) PullServerFileAndWait(identifier string, file PullDescriptor, interval, timeout time.Duration) (*File, error) {
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+
+	if file.Filename == "" {
+		if file.UseHeader {
+			return nil, errors.New("a filename is required to wait for a pull that uses the response header")
+		}
+
+		u, _ := url.Parse(file.URL)
+		file.Filename = path.Base(u.Path)
+		if file.Filename == "/" || file.Filename == "." {
+			return nil, errors.New("pull url does not contain a filename")
+		}
+	}
+
+	if err := c.PullServerFile(identifier, file); err != nil {
+		return nil, err
+	}
+
+	return c.WaitServerFile(identifier, path.Join("/", file.Directory, file.Filename), interval, timeout)
+}
+
+type uploadSource struct {
+	name string
+	size int64
+	open func() (io.ReadCloser, error)
+}
+
 type Uploader struct {
-	client *Client
-	url    string
-	Path   string
+	client    *Client
+	url       string
+	sources   []uploadSource
+	Path      string
+	Directory string
+	Progress  func(name string, written, total int64)
 }
 
 func (u *Uploader) Client() *Client {
@@ -637,12 +1264,8 @@ func (u *Uploader) URL() string {
 	return u.url
 }
 
-func (u *Uploader) Execute() error {
-	if u.Path == "" {
-		return errors.New("no file path has been specified")
-	}
-
-	info, err := os.Stat(u.Path)
+func (u *Uploader) AddFile(path string) error {
+	info, err := os.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return errors.New("file path does not exist")
@@ -655,30 +1278,146 @@ func (u *Uploader) Execute() error {
 		return errors.New("path must go to a file not a directory")
 	}
 
-	file, err := os.Open(u.Path)
+	u.sources = append(u.sources, uploadSource{
+		name: info.Name(),
+		size: info.Size(),
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	})
+
+	return nil
+}
+
+// AddReader adds a file to the upload from a reader. The size is only used
+// for progress reporting and can be -1 if it is not known.
+func (u *Uploader) AddReader(name string, r io.Reader, size int64) {
+	u.sources = append(u.sources, uploadSource{
+		name: name,
+		size: size,
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(r), nil
+		},
+	})
+}
+
+// Execute streams all added files to the daemon in a single multipart
+// request without buffering them in memory.
+func (u *Uploader) Execute() error {
+	if u.Path != "" {
+		sources := u.sources
+		u.sources = nil
+		if err := u.AddFile(u.Path); err != nil {
+			u.sources = sources
+			return err
+		}
+		u.sources = append(u.sources, sources...)
+		u.Path = ""
+	}
+
+	if len(u.sources) == 0 {
+		return errors.New("no files have been specified")
+	}
+
+	target, err := url.Parse(u.URL())
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	if u.Directory != "" {
+		query := target.Query()
+		query.Set("directory", u.Directory)
+		target.RawQuery = query.Encode()
+	}
 
-	body := bytes.Buffer{}
-	writer := multipart.NewWriter(&body)
-	part, _ := writer.CreateFormFile("files", info.Name())
-	io.Copy(part, file)
-	writer.Close()
+	var total int64
+	for _, s := range u.sources {
+		if s.size < 0 {
+			total = -1
+			break
+		}
+		total += s.size
+	}
 
-	res, err := u.client.Http.Post(u.URL(), writer.FormDataContentType(), &body)
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	written := make(chan error, 1)
+	go func() {
+		err := u.write(writer, total)
+		pw.CloseWithError(err)
+		written <- err
+	}()
+
+	req, _ := http.NewRequest("POST", target.String(), pr)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("User-Agent", agent(u.client.userAgent))
+
+	res, err := u.client.Http.Do(req)
+	pr.Close()
+
+	// a failure reading the files is more useful than the transport error
+	if werr := <-written; werr != nil && werr != io.ErrClosedPipe {
+		if err == nil {
+			res.Body.Close()
+		}
+		return werr
+	}
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		return fmt.Errorf("recieved an unexpected response: %s", res.Status)
 	}
 
+	u.sources = nil
 	return nil
 }
 
+func (u *Uploader) write(writer *multipart.Writer, total int64) error {
+	var written int64
+	for _, s := range u.sources {
+		file, err := s.open()
+		if err != nil {
+			return err
+		}
+
+		part, err := writer.CreateFormFile("files", s.name)
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		var dst io.Writer = part
+		if u.Progress != nil {
+			name := s.name
+			dst = &progressWriter{w: part, fn: func(n int64) {
+				written += n
+				u.Progress(name, written, total)
+			}}
+		}
+
+		_, err = io.Copy(dst, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+type progressWriter struct {
+	w  io.Writer
+	fn func(n int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.fn(int64(n))
+	return n, err
+}
+
 func (c *Client) UploadServerFile(identifier, path string) (*Uploader, error) {
 	req := c.newRequest("GET", fmt.Sprintf("/servers/%s/files/upload", identifier), nil)
 	res, err := c.Http.Do(req)
@@ -700,6 +1439,6 @@ func (c *Client) UploadServerFile(identifier, path string) (*Uploader, error) {
 		return nil, err
 	}
 
-	up := &Uploader{client: c, url: model.Attributes.URL}
+	up := &Uploader{client: c, url: model.Attributes.URL, Path: path}
 	return up, nil
 }
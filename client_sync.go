@@ -0,0 +1,501 @@
+package crocgodyl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SyncOptions struct {
+	Include     []string
+	Exclude     []string
+	Delete      bool
+	DryRun      bool
+	Checksum    bool
+	Concurrency int
+}
+
+// match reports whether a slash separated path relative to the sync root is
+// selected. Patterns are matched against both the full path and the base name.
+func (o SyncOptions) match(rel string, dir bool) bool {
+	for _, p := range o.Exclude {
+		if globMatch(p, rel) {
+			return false
+		}
+	}
+
+	// include patterns only select files, directories are always walked
+	if dir || len(o.Include) == 0 {
+		return true
+	}
+	for _, p := range o.Include {
+		if globMatch(p, rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func globMatch(pattern, rel string) bool {
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, path.Base(rel))
+	return ok
+}
+
+const (
+	SyncUpload   = "upload"
+	SyncDownload = "download"
+	SyncDelete   = "delete"
+	SyncMkdir    = "mkdir"
+)
+
+type SyncAction struct {
+	Op   string
+	Path string
+	Size int64
+	Err  error
+}
+
+type SyncSummary struct {
+	Uploaded   int
+	Downloaded int
+	Deleted    int
+	Created    int
+	Unchanged  int
+	Bytes      int64
+	Failed     int
+	DryRun     bool
+	Actions    []*SyncAction
+}
+
+func (s *SyncSummary) record(a *SyncAction) {
+	s.Actions = append(s.Actions, a)
+	if a.Err != nil {
+		s.Failed++
+		return
+	}
+
+	switch a.Op {
+	case SyncUpload:
+		s.Uploaded++
+		s.Bytes += a.Size
+	case SyncDownload:
+		s.Downloaded++
+		s.Bytes += a.Size
+	case SyncDelete:
+		s.Deleted++
+	case SyncMkdir:
+		s.Created++
+	}
+}
+
+func (s *SyncSummary) err() error {
+	if s.Failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d sync operations failed", s.Failed, len(s.Actions))
+}
+
+type localEntry struct {
+	path string
+	info fs.FileInfo
+}
+
+func walkLocal(root string, opts SyncOptions) (map[string]*localEntry, error) {
+	entries := map[string]*localEntry{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if !opts.match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries[rel] = &localEntry{path: p, info: info}
+		return nil
+	})
+
+	return entries, err
+}
+
+func (c *Client) walkRemote(identifier, root string, opts SyncOptions) (map[string]*File, error) {
+	entries := map[string]*File{}
+
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		files, err := c.GetServerFiles(identifier, path.Join(root, rel))
+		if err != nil {
+			return err
+		}
+
+		for _, f := range files {
+			p := path.Join(rel, f.Name)
+			if f.IsSymlink || !opts.match(p, !f.IsFile) {
+				continue
+			}
+
+			entries[p] = f
+			if !f.IsFile {
+				if err = walk(p); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return entries, walk("")
+}
+
+// sortedKeys returns the paths in order, which puts parent directories before
+// their children.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// topLevel drops paths whose parent directory is also in the list so a
+// directory is removed once instead of file by file. Paths are sorted with
+// "/" before any other byte, so the children of a directory directly follow
+// it and do not end up after a sibling such as "a-b" for "a".
+func topLevel(paths []string) []string {
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.ReplaceAll(paths[i], "/", "\x00") < strings.ReplaceAll(paths[j], "/", "\x00")
+	})
+
+	var out []string
+	for _, p := range paths {
+		if n := len(out); n > 0 && strings.HasPrefix(p, out[n-1]+"/") {
+			continue
+		}
+		out = append(out, p)
+	}
+
+	return out
+}
+
+func (o SyncOptions) run(actions []*SyncAction, fn func(a *SyncAction) error) {
+	limit := o.Concurrency
+	if limit <= 0 {
+		limit = 4
+	}
+
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	for _, a := range actions {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(a *SyncAction) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			a.Err = fn(a)
+		}(a)
+	}
+	wg.Wait()
+}
+
+func hashLocalFile(p string) (string, error) {
+	file, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Client) hashServerFile(identifier, file string) (string, error) {
+	h := sha256.New()
+	if err := c.fetchServerFile(identifier, file, h); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *Client) fetchServerFile(identifier, file string, w io.Writer) error {
+	dl, err := c.DownloadServerFile(identifier, file)
+	if err != nil {
+		return err
+	}
+
+	_, err = dl.WriteTo(w)
+	return err
+}
+
+func (c *Client) sameContent(identifier, remote, local string) (bool, error) {
+	lh, err := hashLocalFile(local)
+	if err != nil {
+		return false, err
+	}
+
+	rh, err := c.hashServerFile(identifier, remote)
+	if err != nil {
+		return false, err
+	}
+
+	return lh == rh, nil
+}
+
+// SyncToServer makes the remote directory match the local one. Files are
+// uploaded when they are missing remotely, differ in size, were modified
+// locally after the remote copy or, with Checksum set, differ in content.
+func (c *Client) SyncToServer(identifier, localDir, remoteDir string, opts SyncOptions) (*SyncSummary, error) {
+	local, err := walkLocal(localDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := c.walkRemote(identifier, remoteDir, opts)
+	if err != nil {
+		if fsError(err) != fs.ErrNotExist {
+			return nil, err
+		}
+
+		dir, name := path.Split(path.Clean(remoteDir))
+		if !opts.DryRun {
+			if err = c.CreateServerFileFolder(identifier, CreateFolderDescriptor{Root: dir, Name: name}); err != nil {
+				return nil, err
+			}
+		}
+		remote = map[string]*File{}
+	}
+
+	summary := &SyncSummary{DryRun: opts.DryRun}
+	var uploads []*SyncAction
+	dirs := map[string]bool{}
+
+	for rel, l := range local {
+		r, ok := remote[rel]
+		if l.info.IsDir() {
+			if !ok && len(opts.Include) == 0 {
+				dirs[rel] = true
+			}
+			continue
+		}
+
+		changed := !ok || r.Size != l.info.Size()
+		if !changed && opts.Checksum {
+			same, err := c.sameContent(identifier, path.Join(remoteDir, rel), l.path)
+			if err != nil {
+				return nil, err
+			}
+			changed = !same
+		} else if !changed && r.ModifiedAt != nil {
+			changed = l.info.ModTime().After(*r.ModifiedAt)
+		}
+
+		if changed {
+			uploads = append(uploads, &SyncAction{Op: SyncUpload, Path: rel, Size: l.info.Size()})
+			for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+				if _, ok := remote[dir]; !ok {
+					dirs[dir] = true
+				}
+			}
+		} else {
+			summary.Unchanged++
+		}
+	}
+
+	var deletes []string
+	if opts.Delete {
+		for rel, r := range remote {
+			if _, ok := local[rel]; !ok && (r.IsFile || len(opts.Include) == 0) {
+				deletes = append(deletes, rel)
+			}
+		}
+	}
+
+	for _, rel := range sortedKeys(dirs) {
+		a := &SyncAction{Op: SyncMkdir, Path: rel}
+		if !opts.DryRun {
+			dir, name := path.Split(path.Join(remoteDir, rel))
+			a.Err = c.CreateServerFileFolder(identifier, CreateFolderDescriptor{Root: dir, Name: name})
+		}
+		summary.record(a)
+	}
+
+	if !opts.DryRun {
+		opts.run(uploads, func(a *SyncAction) error {
+			up, err := c.UploadServerFile(identifier, local[a.Path].path)
+			if err != nil {
+				return err
+			}
+			up.Directory = path.Join(remoteDir, path.Dir(a.Path))
+			return up.Execute()
+		})
+	}
+	for _, a := range uploads {
+		summary.record(a)
+	}
+
+	for _, rel := range topLevel(deletes) {
+		a := &SyncAction{Op: SyncDelete, Path: rel}
+		if !opts.DryRun {
+			dir, name := path.Split(path.Join(remoteDir, rel))
+			a.Err = c.DeleteServerFiles(identifier, DeleteFilesDescriptor{Root: dir, Files: []string{name}})
+		}
+		summary.record(a)
+	}
+
+	return summary, summary.err()
+}
+
+// SyncFromServer makes the local directory match the remote one. Downloaded
+// files take the remote modification time so unchanged files are skipped on
+// the next run.
+func (c *Client) SyncFromServer(identifier, remoteDir, localDir string, opts SyncOptions) (*SyncSummary, error) {
+	remote, err := c.walkRemote(identifier, remoteDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := walkLocal(localDir, opts)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	summary := &SyncSummary{DryRun: opts.DryRun}
+	var downloads []*SyncAction
+	dirs := map[string]bool{}
+
+	for rel, r := range remote {
+		l, ok := local[rel]
+		if !r.IsFile {
+			if !ok && len(opts.Include) == 0 {
+				dirs[rel] = true
+			}
+			continue
+		}
+
+		changed := !ok || r.Size != l.info.Size()
+		if !changed && opts.Checksum {
+			same, err := c.sameContent(identifier, path.Join(remoteDir, rel), l.path)
+			if err != nil {
+				return nil, err
+			}
+			changed = !same
+		} else if !changed && r.ModifiedAt != nil {
+			changed = !l.info.ModTime().Truncate(time.Second).Equal(r.ModifiedAt.Truncate(time.Second))
+		}
+
+		if changed {
+			downloads = append(downloads, &SyncAction{Op: SyncDownload, Path: rel, Size: r.Size})
+			for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+				if _, ok := local[dir]; !ok {
+					dirs[dir] = true
+				}
+			}
+		} else {
+			summary.Unchanged++
+		}
+	}
+
+	var deletes []string
+	if opts.Delete {
+		for rel, l := range local {
+			if _, ok := remote[rel]; !ok && (!l.info.IsDir() || len(opts.Include) == 0) {
+				deletes = append(deletes, rel)
+			}
+		}
+	}
+
+	for _, rel := range sortedKeys(dirs) {
+		a := &SyncAction{Op: SyncMkdir, Path: rel}
+		if !opts.DryRun {
+			a.Err = os.MkdirAll(filepath.Join(localDir, filepath.FromSlash(rel)), 0o755)
+		}
+		summary.record(a)
+	}
+
+	if !opts.DryRun {
+		opts.run(downloads, func(a *SyncAction) error {
+			return c.downloadTo(identifier, path.Join(remoteDir, a.Path),
+				filepath.Join(localDir, filepath.FromSlash(a.Path)), remote[a.Path].ModifiedAt)
+		})
+	}
+	for _, a := range downloads {
+		summary.record(a)
+	}
+
+	for _, rel := range topLevel(deletes) {
+		a := &SyncAction{Op: SyncDelete, Path: rel}
+		if !opts.DryRun {
+			a.Err = os.RemoveAll(filepath.Join(localDir, filepath.FromSlash(rel)))
+		}
+		summary.record(a)
+	}
+
+	return summary, summary.err()
+}
+
+func (c *Client) downloadTo(identifier, remote, local string, modified *time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		return err
+	}
+
+	// write next to the target and rename so a failed transfer never leaves
+	// a truncated file behind
+	tmp, err := os.CreateTemp(filepath.Dir(local), "."+filepath.Base(local)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = c.fetchServerFile(identifier, remote, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), local); err != nil {
+		return err
+	}
+
+	if modified != nil {
+		return os.Chtimes(local, time.Now(), *modified)
+	}
+	return nil
+}
@@ -0,0 +1,443 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type ConsoleStats struct {
+	MemoryBytes      int64   `json:"memory_bytes"`
+	MemoryLimitBytes int64   `json:"memory_limit_bytes"`
+	CPUAbsolute      float64 `json:"cpu_absolute"`
+	Network          struct {
+		RxBytes int64 `json:"rx_bytes"`
+		TxBytes int64 `json:"tx_bytes"`
+	} `json:"network"`
+	State     ServerState `json:"state"`
+	Uptime    int64       `json:"uptime"`
+	DiskBytes int64       `json:"disk_bytes"`
+}
+
+type BackupCompleted struct {
+	UUID         string `json:"uuid"`
+	Successful   bool   `json:"is_successful"`
+	Checksum     string `json:"checksum"`
+	ChecksumType string `json:"checksum_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+// ConsoleEvent is a single message received from the daemon. Depending on the
+// event only one of Line, State, Stats or Backup is set, the raw arguments are
+// always available in Args.
+type ConsoleEvent struct {
+	Event  string
+	Args   []string
+	Line   string
+	State  ServerState
+	Stats  *ConsoleStats
+	Backup *BackupCompleted
+}
+
+type consoleMessage struct {
+	Event string   `json:"event"`
+	Args  []string `json:"args,omitempty"`
+}
+
+type Console struct {
+	Identifier string
+	Dialer     *websocket.Dialer
+	Timeout    time.Duration
+
+	OnOutput          func(line string)
+	OnInstallOutput   func(line string)
+	OnStatus          func(state ServerState)
+	OnStats           func(stats *ConsoleStats)
+	OnDaemonError     func(message string)
+	OnBackupCompleted func(backup *BackupCompleted)
+	OnEvent           func(event *ConsoleEvent)
+
+	client  *Client
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	subs    map[*consoleSubscription]struct{}
+	closing chan struct{}
+	done    chan struct{}
+	err     error
+
+	// dispatching is set while the read loop runs callbacks, Close cannot wait
+	// for the read loop then as a callback may be the one calling it
+	dispatching bool
+}
+
+type consoleSubscription struct {
+	ch   chan *ConsoleEvent
+	stop chan struct{}
+}
+
+func (c *Client) NewConsole(identifier string) *Console {
+	return &Console{
+		Identifier: identifier,
+		Dialer:     c.websocketDialer(),
+		Timeout:    15 * time.Second,
+		client:     c,
+		subs:       map[*consoleSubscription]struct{}{},
+	}
+}
+
+func (c *Console) Client() *Client {
+	return c.client
+}
+
+// Connect fetches fresh websocket credentials from the panel, dials the daemon
+// and authenticates. Events are dispatched on a separate goroutine until the
+// connection is closed.
+func (c *Console) Connect() error {
+	c.mu.Lock()
+	if c.conn != nil {
+		c.mu.Unlock()
+		return errors.New("console is already connected")
+	}
+	c.mu.Unlock()
+
+	auth, err := c.client.GetServerWebSocket(c.Identifier)
+	if err != nil {
+		return err
+	}
+
+	// wings rejects connections that do not originate from the panel
+	header := http.Header{}
+	header.Set("Origin", strings.TrimRight(c.client.PanelURL, "/"))
+
+	dialer := c.Dialer
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	conn, _, err := dialer.Dial(auth.Socket, header)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	c.closing = make(chan struct{})
+	c.done = make(chan struct{})
+	c.err = nil
+	c.mu.Unlock()
+
+	if err = c.authenticate(auth.Token); err != nil {
+		c.fail(err)
+		c.shutdown()
+		return err
+	}
+
+	go c.readLoop()
+	return nil
+}
+
+func (c *Console) authenticate(token string) error {
+	if c.Timeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.Timeout))
+		defer c.conn.SetReadDeadline(time.Time{})
+	}
+
+	if err := c.Send("auth", token); err != nil {
+		return err
+	}
+
+	for {
+		var msg consoleMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+
+		switch msg.Event {
+		case "auth success":
+			return nil
+		case "jwt error":
+			return errors.New("websocket authentication failed: " + strings.Join(msg.Args, " "))
+		}
+	}
+}
+
+func (c *Console) reauthenticate() {
+	auth, err := c.client.GetServerWebSocket(c.Identifier)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+
+	if err = c.Send("auth", auth.Token); err != nil {
+		c.fail(err)
+	}
+}
+
+func (c *Console) readLoop() {
+	defer c.shutdown()
+
+	for {
+		var msg consoleMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				c.fail(err)
+			}
+			return
+		}
+
+		switch msg.Event {
+		case "token expiring", "token expired":
+			go c.reauthenticate()
+			continue
+		case "jwt error":
+			c.fail(errors.New("websocket authentication failed: " + strings.Join(msg.Args, " ")))
+			return
+		}
+
+		c.mu.Lock()
+		c.dispatching = true
+		c.mu.Unlock()
+
+		c.dispatch(decodeConsoleEvent(msg))
+
+		c.mu.Lock()
+		c.dispatching = false
+		c.mu.Unlock()
+	}
+}
+
+func decodeConsoleEvent(msg consoleMessage) *ConsoleEvent {
+	event := &ConsoleEvent{Event: msg.Event, Args: msg.Args}
+
+	// backup events are published with the backup uuid appended
+	if strings.HasPrefix(event.Event, "backup completed") {
+		event.Event = "backup completed"
+	}
+
+	arg := ""
+	if len(msg.Args) > 0 {
+		arg = msg.Args[0]
+	}
+
+	switch event.Event {
+	case "console output", "install output", "daemon message", "daemon error":
+		event.Line = arg
+	case "status":
+		event.State = ServerState(arg)
+	case "stats":
+		var stats ConsoleStats
+		if json.Unmarshal([]byte(arg), &stats) == nil {
+			event.Stats = &stats
+			event.State = stats.State
+		}
+	case "backup completed":
+		var backup BackupCompleted
+		if json.Unmarshal([]byte(arg), &backup) == nil {
+			event.Backup = &backup
+		}
+	}
+
+	return event
+}
+
+func (c *Console) dispatch(event *ConsoleEvent) {
+	switch event.Event {
+	case "console output":
+		if c.OnOutput != nil {
+			c.OnOutput(event.Line)
+		}
+	case "install output":
+		if c.OnInstallOutput != nil {
+			c.OnInstallOutput(event.Line)
+		}
+	case "status":
+		if c.OnStatus != nil {
+			c.OnStatus(event.State)
+		}
+	case "stats":
+		if c.OnStats != nil && event.Stats != nil {
+			c.OnStats(event.Stats)
+		}
+	case "daemon error":
+		if c.OnDaemonError != nil {
+			c.OnDaemonError(event.Line)
+		}
+	case "backup completed":
+		if c.OnBackupCompleted != nil && event.Backup != nil {
+			c.OnBackupCompleted(event.Backup)
+		}
+	}
+
+	if c.OnEvent != nil {
+		c.OnEvent(event)
+	}
+
+	c.mu.Lock()
+	subs := make([]*consoleSubscription, 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	closing := c.closing
+	c.mu.Unlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.ch <- event:
+		case <-sub.stop:
+		case <-closing:
+			return
+		}
+	}
+}
+
+// Subscribe returns a channel receiving every event from the daemon and a
+// function to stop the subscription. The channel is closed when the
+// connection ends, and is returned closed when it already has. Subscribers
+// must keep up with the stream as slow readers hold up delivery of events to
+// everyone else.
+func (c *Console) Subscribe(size int) (<-chan *ConsoleEvent, func()) {
+	sub := &consoleSubscription{
+		ch:   make(chan *ConsoleEvent, size),
+		stop: make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	if c.done != nil && c.conn == nil {
+		c.mu.Unlock()
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	c.subs[sub] = struct{}{}
+	c.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.subs, sub)
+			c.mu.Unlock()
+			close(sub.stop)
+		})
+	}
+}
+
+func (c *Console) Send(event string, args ...string) error {
+	c.mu.Lock()
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return errors.New("console is not connected")
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return conn.WriteJSON(consoleMessage{Event: event, Args: args})
+}
+
+func (c *Console) SendCommand(command string) error {
+	return c.Send("send command", command)
+}
+
+func (c *Console) SetState(signal PowerSignal) error {
+	if !signal.Valid() {
+		return fmt.Errorf("unknown power signal %q", string(signal))
+	}
+	return c.Send("set state", string(signal))
+}
+
+func (c *Console) RequestLogs() error {
+	return c.Send("send logs")
+}
+
+func (c *Console) RequestStats() error {
+	return c.Send("send stats")
+}
+
+func (c *Console) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+}
+
+func (c *Console) shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+	for sub := range c.subs {
+		delete(c.subs, sub)
+		close(sub.ch)
+	}
+	close(c.done)
+}
+
+// Done is closed once the connection to the daemon is lost or closed.
+func (c *Console) Done() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+func (c *Console) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close ends the connection and waits for the read loop to shut down. Called
+// from a callback it returns without waiting, the read loop then shuts down
+// once the callback returns.
+func (c *Console) Close() error {
+	c.mu.Lock()
+	conn := c.conn
+	done := c.done
+	dispatching := c.dispatching
+	if conn != nil {
+		select {
+		case <-c.closing:
+		default:
+			close(c.closing)
+		}
+	}
+	c.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	c.writeMu.Lock()
+	err := conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+
+	if dispatching {
+		go func() {
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				conn.Close()
+			}
+		}()
+		return err
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		conn.Close()
+		<-done
+	}
+
+	return err
+}
@@ -0,0 +1,336 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// consoleStandIn serves the websocket credentials endpoint of the panel and a
+// daemon websocket that accepts the tokens handed out by it.
+type consoleStandIn struct {
+	server *httptest.Server
+	reject bool
+
+	mu       sync.Mutex
+	issued   int
+	origin   string
+	send     chan consoleMessage
+	received chan consoleMessage
+}
+
+func newConsoleStandIn(t *testing.T) *consoleStandIn {
+	s := &consoleStandIn{
+		send:     make(chan consoleMessage, 16),
+		received: make(chan consoleMessage, 16),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/client/servers/abc/websocket", s.credentials)
+	mux.HandleFunc("/ws", s.socket)
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *consoleStandIn) credentials(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.issued++
+	token := fmt.Sprintf("token-%d", s.issued)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": WebSocketAuth{
+			Socket: "ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws",
+			Token:  token,
+		},
+	})
+}
+
+func (s *consoleStandIn) valid(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.reject && token == fmt.Sprintf("token-%d", s.issued)
+}
+
+func (s *consoleStandIn) socket(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.origin = r.Header.Get("Origin")
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case msg := <-s.send:
+				if conn.WriteJSON(msg) != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		var msg consoleMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		if msg.Event == "auth" {
+			if len(msg.Args) == 1 && s.valid(msg.Args[0]) {
+				s.send <- consoleMessage{Event: "auth success"}
+			} else {
+				s.send <- consoleMessage{Event: "jwt error", Args: []string{"invalid token"}}
+			}
+		}
+		s.received <- msg
+	}
+}
+
+func (s *consoleStandIn) expect(t *testing.T, event string) consoleMessage {
+	t.Helper()
+
+	for {
+		select {
+		case msg := <-s.received:
+			if msg.Event == event {
+				return msg
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a %q message", event)
+		}
+	}
+}
+
+func (s *consoleStandIn) console(t *testing.T) *Console {
+	client, err := NewClient(s.server.URL, "ptlc_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	console := client.NewConsole("abc")
+	console.Timeout = 5 * time.Second
+	return console
+}
+
+func nextEvent(t *testing.T, events <-chan *ConsoleEvent) *ConsoleEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return nil
+}
+
+func TestConsoleAuthentication(t *testing.T) {
+	s := newConsoleStandIn(t)
+	console := s.console(t)
+
+	if err := console.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer console.Close()
+
+	if msg := s.expect(t, "auth"); len(msg.Args) != 1 || msg.Args[0] != "token-1" {
+		t.Fatalf("unexpected auth arguments %v", msg.Args)
+	}
+
+	s.mu.Lock()
+	origin := s.origin
+	s.mu.Unlock()
+	if origin != s.server.URL {
+		t.Fatalf("unexpected origin %q, want %q", origin, s.server.URL)
+	}
+
+	if err := console.Connect(); err == nil {
+		t.Fatal("expected an error connecting twice")
+	}
+}
+
+func TestConsoleReauthenticates(t *testing.T) {
+	s := newConsoleStandIn(t)
+	console := s.console(t)
+
+	if err := console.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer console.Close()
+	s.expect(t, "auth")
+
+	for i, event := range []string{"token expiring", "token expired"} {
+		s.send <- consoleMessage{Event: event}
+
+		want := fmt.Sprintf("token-%d", i+2)
+		if msg := s.expect(t, "auth"); len(msg.Args) != 1 || msg.Args[0] != want {
+			t.Fatalf("%s: unexpected auth arguments %v, want %s", event, msg.Args, want)
+		}
+	}
+
+	// the connection stays usable after both renewals
+	if err := console.SendCommand("say hi"); err != nil {
+		t.Fatal(err)
+	}
+	if msg := s.expect(t, "send command"); len(msg.Args) != 1 || msg.Args[0] != "say hi" {
+		t.Fatalf("unexpected command arguments %v", msg.Args)
+	}
+	if err := console.Err(); err != nil {
+		t.Fatalf("unexpected console error: %v", err)
+	}
+}
+
+func TestConsoleRejectedToken(t *testing.T) {
+	s := newConsoleStandIn(t)
+	s.reject = true
+	console := s.console(t)
+
+	err := console.Connect()
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+
+	select {
+	case <-console.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("console was not shut down")
+	}
+}
+
+func TestConsoleEvents(t *testing.T) {
+	s := newConsoleStandIn(t)
+	console := s.console(t)
+
+	var (
+		mu       sync.Mutex
+		statuses []ServerState
+	)
+	console.OnStatus = func(state ServerState) {
+		mu.Lock()
+		statuses = append(statuses, state)
+		mu.Unlock()
+	}
+
+	if err := console.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	events, cancel := console.Subscribe(16)
+	defer cancel()
+
+	s.send <- consoleMessage{Event: "status", Args: []string{"starting"}}
+	s.send <- consoleMessage{Event: "console output", Args: []string{"hello world"}}
+	s.send <- consoleMessage{Event: "stats", Args: []string{`{"memory_bytes":1024,"cpu_absolute":12.5,"state":"running","network":{"rx_bytes":7}}`}}
+	s.send <- consoleMessage{Event: "backup completed 1234", Args: []string{`{"uuid":"1234","is_successful":true,"file_size":42}`}}
+
+	event := nextEvent(t, events)
+	if event.Event != "status" || event.State != StateStarting {
+		t.Fatalf("unexpected status event %+v", event)
+	}
+
+	event = nextEvent(t, events)
+	if event.Event != "console output" || event.Line != "hello world" {
+		t.Fatalf("unexpected output event %+v", event)
+	}
+
+	event = nextEvent(t, events)
+	if event.Stats == nil || event.Stats.MemoryBytes != 1024 || event.Stats.CPUAbsolute != 12.5 ||
+		event.Stats.Network.RxBytes != 7 || event.State != StateRunning {
+		t.Fatalf("unexpected stats event %+v", event)
+	}
+
+	event = nextEvent(t, events)
+	if event.Event != "backup completed" || event.Backup == nil || event.Backup.UUID != "1234" ||
+		!event.Backup.Successful || event.Backup.FileSize != 42 {
+		t.Fatalf("unexpected backup event %+v", event)
+	}
+
+	mu.Lock()
+	if len(statuses) != 1 || statuses[0] != StateStarting {
+		t.Fatalf("unexpected statuses %v", statuses)
+	}
+	mu.Unlock()
+
+	if err := console.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("expected the event channel to be closed")
+	}
+}
+
+func TestConsoleCloseFromCallback(t *testing.T) {
+	s := newConsoleStandIn(t)
+	console := s.console(t)
+
+	closed := make(chan error, 1)
+	console.OnOutput = func(string) {
+		closed <- console.Close()
+	}
+
+	if err := console.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	s.send <- consoleMessage{Event: "console output", Args: []string{"bye"}}
+
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close from a callback did not return")
+	}
+
+	select {
+	case <-console.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("console was not shut down")
+	}
+}
+
+func TestConsoleSubscribeAfterClose(t *testing.T) {
+	s := newConsoleStandIn(t)
+	console := s.console(t)
+
+	if err := console.Connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := console.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	events, cancel := console.Subscribe(1)
+	defer cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the event channel to be closed")
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) clientCommand() *command {
+	return &command{
+		name:  "client",
+		usage: "servers|console|power|resources|files",
+		actions: map[string]func([]string) error{
+			"servers":   c.listClientServers,
+			"console":   c.attachConsole,
+			"power":     c.setPower,
+			"resources": c.showResources,
+			"files":     c.files,
+		},
+	}
+}
+
+func (c *cli) listClientServers(args []string) error {
+	fs := newFlags("client servers")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	servers, err := client.GetServers()
+	if err != nil {
+		return err
+	}
+
+	t := &table{headers: []string{"IDENTIFIER", "NAME", "NODE", "STATUS", "OWNER"}}
+	for _, s := range servers {
+		t.add(s.Identifier, s.Name, s.Node, s.Status.String(), s.ServerOwner)
+	}
+
+	return c.print(servers, t)
+}
+
+func serverArg(name string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", usageErrorf("usage: croc %s <server>", name)
+	}
+	return args[0], nil
+}
+
+// attachConsole streams the console of a server to stdout and sends every line
+// read from stdin as a command until stdin is closed or interrupted.
+func (c *cli) attachConsole(args []string) error {
+	fs := newFlags("client console")
+	logs := fs.Bool("logs", true, "show recent console output when attaching")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	identifier, err := serverArg(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	console := client.NewConsole(identifier)
+	console.OnOutput = func(line string) {
+		fmt.Fprintln(c.out, line)
+	}
+	console.OnInstallOutput = func(line string) {
+		fmt.Fprintln(c.out, line)
+	}
+	console.OnStatus = func(state croc.ServerState) {
+		fmt.Fprintf(c.errOut, "[server is %s]\n", state)
+	}
+	console.OnDaemonError = func(message string) {
+		fmt.Fprintf(c.errOut, "[daemon error: %s]\n", message)
+	}
+
+	if err = console.Connect(); err != nil {
+		return err
+	}
+	defer console.Close()
+
+	if *logs {
+		if err = console.RequestLogs(); err != nil {
+			return err
+		}
+	}
+
+	input := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(c.in)
+		for scanner.Scan() {
+			if err := console.SendCommand(scanner.Text()); err != nil {
+				input <- err
+				return
+			}
+		}
+		input <- scanner.Err()
+	}()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	select {
+	case err = <-input:
+		return err
+	case <-interrupt:
+		return nil
+	case <-console.Done():
+		return console.Err()
+	}
+}
+
+func (c *cli) setPower(args []string) error {
+	fs := newFlags("client power")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return usageErrorf("usage: croc client power start|stop|restart|kill <server>")
+	}
+
+	signal, err := croc.ParsePowerSignal(args[0])
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	if err = client.SetServerPowerState(args[1], signal); err != nil {
+		return err
+	}
+
+	c.message("sent %s to %s", signal, args[1])
+	return nil
+}
+
+func resourcesTable(r *croc.Resources) *table {
+	t := &table{headers: []string{"STATE", "CPU", "MEMORY", "DISK", "NET RX", "NET TX", "UPTIME"}}
+	t.add(r.State.String(), fmt.Sprintf("%.1f%%", r.Usage.CPUAbsolute), formatBytes(r.Usage.MemoryBytes), formatBytes(r.Usage.DiskBytes),
+		formatBytes(r.Usage.NetworkRxBytes), formatBytes(r.Usage.NetworkTxBytes),
+		(time.Duration(r.Usage.Uptime) * time.Millisecond).Truncate(time.Second).String())
+	return t
+}
+
+// showResources prints the resource usage of a server once, or every interval
+// with -watch until interrupted. Watching with json output writes one object
+// per line.
+func (c *cli) showResources(args []string) error {
+	fs := newFlags("client resources")
+	watch := fs.Duration("watch", 0, "refresh interval, the usage is shown once if not set")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	identifier, err := serverArg(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	if *watch <= 0 {
+		res, err := client.GetServerResources(identifier)
+		if err != nil {
+			return err
+		}
+		return c.print(res, resourcesTable(res))
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(*watch)
+	defer ticker.Stop()
+
+	for first := true; ; first = false {
+		res, err := client.GetServerResources(identifier)
+		if err != nil {
+			return err
+		}
+
+		switch c.format {
+		case "json":
+			if err = json.NewEncoder(c.out).Encode(res); err != nil {
+				return err
+			}
+		case "yaml":
+			if !first {
+				fmt.Fprintln(c.out, "---")
+			}
+			if err = c.print(res, nil); err != nil {
+				return err
+			}
+		default:
+			// every refresh is a row of one table, so fixed widths are used
+			// instead of aligning each refresh on its own
+			t := resourcesTable(res)
+			if first {
+				printRow(c.out, t.headers)
+			}
+			printRow(c.out, t.rows[0])
+		}
+
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func printRow(w io.Writer, cols []string) {
+	fmt.Fprintf(w, "%-10s  %7s  %10s  %10s  %10s  %10s  %s\n", cols[0], cols[1], cols[2], cols[3], cols[4], cols[5], cols[6])
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+const (
+	exitError        = 1
+	exitUsage        = 2
+	exitUnauthorized = 3
+	exitNotFound     = 4
+	exitInvalid      = 5
+	exitConflict     = 6
+	exitRateLimited  = 7
+	exitPanelError   = 8
+)
+
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// reportedError wraps an error whose details were already written, so only
+// its exit code is used.
+type reportedError struct {
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() error {
+	return e.err
+}
+
+func exitCode(err error) int {
+	var usage *usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+
+	if errors.Is(err, croc.ErrInvalidKey) || errors.Is(err, croc.ErrPermissionDenied) {
+		return exitUnauthorized
+	}
+
+	var errs *croc.ApiError
+	if !errors.As(err, &errs) || len(errs.Errors) == 0 {
+		return exitError
+	}
+
+	status, _ := strconv.Atoi(errs.Errors[0].Status)
+	switch {
+	case status == 401 || status == 403:
+		return exitUnauthorized
+	case status == 404:
+		return exitNotFound
+	case status == 400 || status == 422:
+		return exitInvalid
+	case status == 409:
+		return exitConflict
+	case status == 429:
+		return exitRateLimited
+	case status >= 500:
+		return exitPanelError
+	default:
+		return exitError
+	}
+}
+
+// printError writes the error unless it was already reported.
+func printError(w io.Writer, err error) {
+	var reported *reportedError
+	if errors.As(err, &reported) {
+		return
+	}
+
+	fmt.Fprintf(w, "croc: %s\n", errorText(err))
+}
+
+// errorText lists every error returned by the panel, the ApiError message on
+// its own only says how many there were.
+func errorText(err error) string {
+	var errs *croc.ApiError
+	if errors.As(err, &errs) && len(errs.Errors) > 0 {
+		parts := make([]string, 0, len(errs.Errors))
+		for _, e := range errs.Errors {
+			parts = append(parts, e.Error())
+		}
+		return strings.Join(parts, "; ")
+	}
+
+	return err.Error()
+}
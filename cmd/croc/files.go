@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+const filesUsage = "ls|cat|get|put|rm|mv|chmod"
+
+func (c *cli) files(args []string) error {
+	return subcommand("client files", filesUsage, map[string]func([]string) error{
+		"ls":    c.listFiles,
+		"cat":   c.catFile,
+		"get":   c.getFile,
+		"put":   c.putFiles,
+		"rm":    c.removeFiles,
+		"mv":    c.moveFile,
+		"chmod": c.chmodFiles,
+	}, args)
+}
+
+func filesTable(dir string, files ...*croc.File) *table {
+	t := &table{headers: []string{"MODE", "SIZE", "MODIFIED", "NAME"}}
+	for _, f := range files {
+		name := path.Join(dir, f.Name)
+		if f.IsDir() {
+			name += "/"
+		}
+		t.add(f.Mode, formatBytes(f.Size), f.ModifiedAt, name)
+	}
+	return t
+}
+
+func (c *cli) listFiles(args []string) error {
+	fs := newFlags("client files ls")
+	recursive := fs.Bool("r", false, "list subdirectories recursively")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return usageErrorf("usage: croc client files ls [-r] <server> [directory]")
+	}
+
+	dir := "/"
+	if len(args) == 2 {
+		dir = path.Clean("/" + args[1])
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	if !*recursive {
+		files, err := client.GetServerFiles(args[0], dir)
+		if err != nil {
+			return err
+		}
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Name < files[j].Name
+		})
+
+		return c.print(files, filesTable(dir, files...))
+	}
+
+	found, err := client.ListServerFilesRecursive(args[0], dir)
+	if err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(found))
+	for p := range found {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	t := &table{headers: []string{"MODE", "SIZE", "MODIFIED", "NAME"}}
+	for _, p := range paths {
+		f := found[p]
+		if f.IsDir() {
+			p += "/"
+		}
+		t.add(f.Mode, formatBytes(f.Size), f.ModifiedAt, p)
+	}
+
+	return c.print(found, t)
+}
+
+func (c *cli) catFile(args []string) error {
+	fs := newFlags("client files cat")
+	head := fs.Int("head", 0, "only print the first n lines")
+	tail := fs.Int("tail", 0, "only print the last n lines")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return usageErrorf("usage: croc client files cat [-head n|-tail n] <server> <file>")
+	}
+	if *head > 0 && *tail > 0 {
+		return usageErrorf("-head and -tail can not be combined")
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	switch {
+	case *head > 0:
+		lines, err = client.HeadServerFile(args[0], args[1], *head)
+	case *tail > 0:
+		lines, err = client.TailServerFile(args[0], args[1], *tail)
+	default:
+		r, err := client.OpenServerFile(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		_, err = io.Copy(c.out, r)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(c.out, line)
+	}
+	return nil
+}
+
+func (c *cli) getFile(args []string) error {
+	fs := newFlags("client files get")
+	resume := fs.Bool("resume", false, "continue a partial download")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 || len(args) > 3 {
+		return usageErrorf("usage: croc client files get [-resume] <server> <file> [destination]")
+	}
+
+	dest := "."
+	if len(args) == 3 {
+		dest = args[2]
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	dl, err := client.DownloadServerFile(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	dl.Resume = *resume
+
+	if dest == "-" {
+		_, err = dl.WriteTo(c.out)
+		return err
+	}
+
+	if err = dl.SaveTo(dest); err != nil {
+		return err
+	}
+
+	c.message("downloaded %s (%s)", dl.Path, formatBytes(dl.Size))
+	return nil
+}
+
+func (c *cli) putFiles(args []string) error {
+	fs := newFlags("client files put")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 3 {
+		return usageErrorf("usage: croc client files put <server> <file>... <directory>")
+	}
+
+	identifier, locals, dir := args[0], args[1:len(args)-1], args[len(args)-1]
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	up, err := client.UploadServerFile(identifier, locals[0])
+	if err != nil {
+		return err
+	}
+	for _, l := range locals[1:] {
+		if err = up.AddFile(l); err != nil {
+			return err
+		}
+	}
+	up.Directory = path.Clean("/" + dir)
+
+	if err = up.Execute(); err != nil {
+		return err
+	}
+
+	c.message("uploaded %d file(s) to %s", len(locals), up.Directory)
+	return nil
+}
+
+// runBatch executes the batch and reports every file that failed, the exit
+// code is taken from the first failure.
+func (c *cli) runBatch(batch *croc.FileBatch) error {
+	report, _ := batch.Execute()
+
+	var first error
+	for _, r := range report.Results {
+		if r.Err != nil {
+			fmt.Fprintf(c.errOut, "croc: %s %s: %s\n", r.Op, r.Path, errorText(r.Err))
+			if first == nil {
+				first = &reportedError{err: r.Err}
+			}
+			continue
+		}
+
+		switch r.Op {
+		case croc.BatchRename:
+			c.message("moved %s to %s", r.Path, r.Target)
+		case croc.BatchChmod:
+			c.message("changed mode of %s to %d", r.Path, r.Mode)
+		case croc.BatchDelete:
+			c.message("removed %s", r.Path)
+		}
+	}
+
+	return first
+}
+
+func (c *cli) removeFiles(args []string) error {
+	fs := newFlags("client files rm")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return usageErrorf("usage: croc client files rm <server> <path>...")
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	return c.runBatch(client.NewFileBatch(args[0]).Delete(args[1:]...))
+}
+
+func (c *cli) moveFile(args []string) error {
+	fs := newFlags("client files mv")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 3 {
+		return usageErrorf("usage: croc client files mv <server> <from> <to>")
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	return c.runBatch(client.NewFileBatch(args[0]).Rename(args[1], args[2]))
+}
+
+func (c *cli) chmodFiles(args []string) error {
+	fs := newFlags("client files chmod")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 3 {
+		return usageErrorf("usage: croc client files chmod <server> <mode> <path>...")
+	}
+	if _, err = croc.ParseFileMode(args[1]); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	client, err := c.clientAPI()
+	if err != nil {
+		return err
+	}
+
+	batch := client.NewFileBatch(args[0])
+	for _, p := range args[2:] {
+		batch.Chmod(p, args[1])
+	}
+
+	return c.runBatch(batch)
+}
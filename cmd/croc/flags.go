@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+// newFlags creates the flag set of an action, errors are returned to the
+// caller and reported with the usage exit code. Nothing is written by the flag
+// package itself so errors are not printed twice.
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parse parses the flags of an action and returns its positional arguments.
+// Unlike flag.Parse flags are also accepted after positional arguments, so
+// both "files get -resume abc a.txt" and "files get abc a.txt -resume" work.
+// Global flags such as -o are not known to actions and go before the
+// resource, as in "croc -o json users get 5".
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err == flag.ErrHelp {
+			usage := &strings.Builder{}
+			fs.SetOutput(usage)
+			fs.PrintDefaults()
+			fs.SetOutput(io.Discard)
+			return nil, usageErrorf("usage of %s:\n%s", fs.Name(), strings.TrimRight(usage.String(), "\n"))
+		} else if err != nil {
+			return nil, usageErrorf("invalid arguments for %s: %v", fs.Name(), err)
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// changed returns the names of the flags that were set on the command line.
+func changed(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+func changedAny(set map[string]bool, names ...string) bool {
+	for _, n := range names {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
+
+func parseID(name string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, usageErrorf("usage: croc %s <id>", name)
+	}
+
+	id, err := strconv.Atoi(args[0])
+	if err != nil || id <= 0 {
+		return 0, usageErrorf("invalid id %q", args[0])
+	}
+
+	return id, nil
+}
+
+func noArgs(name string, args []string) error {
+	if len(args) != 0 {
+		return usageErrorf("unexpected arguments for %s: %s", name, strings.Join(args, " "))
+	}
+	return nil
+}
+
+type intList []int
+
+func (l *intList) String() string {
+	parts := make([]string, 0, len(*l))
+	for _, i := range *l {
+		parts = append(parts, strconv.Itoa(i))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *intList) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", part)
+		}
+		*l = append(*l, i)
+	}
+	return nil
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			*l = append(*l, part)
+		}
+	}
+	return nil
+}
+
+// keyValues collects repeated KEY=VALUE flags such as egg variables.
+type keyValues map[string]interface{}
+
+func (kv keyValues) String() string {
+	parts := make([]string, 0, len(kv))
+	for k, v := range kv {
+		parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (kv keyValues) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected KEY=VALUE, got %q", s)
+	}
+
+	kv[s[:i]] = s[i+1:]
+	return nil
+}
+
+// unitValue is a number flag that accepts units, such as "4GiB" for a memory
+// limit.
+type unitValue struct {
+	v      *int64
+	parse  func(string) (int64, error)
+	format func(int64) string
+}
+
+func (u *unitValue) String() string {
+	if u.v == nil {
+		return ""
+	}
+	return u.format(*u.v)
+}
+
+func (u *unitValue) Set(s string) error {
+	v, err := u.parse(s)
+	if err != nil {
+		return err
+	}
+	*u.v = v
+	return nil
+}
+
+func parseMiB(s string) (int64, error) {
+	m, err := croc.ParseMiB(s)
+	return int64(m), err
+}
+
+func formatMiB(v int64) string {
+	return croc.MiB(v).String()
+}
+
+// threadsValue validates a list of cpu threads and stores it in the form the
+// panel uses.
+type threadsValue struct {
+	v *string
+}
+
+func (t *threadsValue) String() string {
+	if t.v == nil {
+		return ""
+	}
+	return *t.v
+}
+
+func (t *threadsValue) Set(s string) error {
+	set, err := croc.ParseCPUSet(s)
+	if err != nil {
+		return err
+	}
+	*t.v = set.String()
+	return nil
+}
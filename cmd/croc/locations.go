@@ -0,0 +1,168 @@
+package main
+
+import (
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) locationsCommand() *command {
+	return &command{
+		name:  "locations",
+		usage: "list|get|create|update|delete",
+		actions: map[string]func([]string) error{
+			"list":   c.listLocations,
+			"get":    c.getLocation,
+			"create": c.createLocation,
+			"update": c.updateLocation,
+			"delete": c.deleteLocation,
+		},
+	}
+}
+
+func locationsTable(locations ...*croc.Location) *table {
+	t := &table{headers: []string{"ID", "SHORT", "LONG", "CREATED"}}
+	for _, l := range locations {
+		t.add(l.ID, l.Short, l.Long, l.CreatedAt)
+	}
+	return t
+}
+
+func (c *cli) listLocations(args []string) error {
+	fs := newFlags("locations list")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	locations, err := app.GetLocations()
+	if err != nil {
+		return err
+	}
+
+	return c.print(locations, locationsTable(locations...))
+}
+
+func (c *cli) getLocation(args []string) error {
+	fs := newFlags("locations get")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	location, err := app.GetLocation(id)
+	if err != nil {
+		return err
+	}
+
+	return c.print(location, locationsTable(location))
+}
+
+func (c *cli) createLocation(args []string) error {
+	fs := newFlags("locations create")
+	short := fs.String("short", "", "short location code")
+	long := fs.String("long", "", "location description")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if *short == "" {
+		return usageErrorf("locations create requires -short")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	location, err := app.CreateLocation(*short, *long)
+	if err != nil {
+		return err
+	}
+
+	return c.print(location, locationsTable(location))
+}
+
+func (c *cli) updateLocation(args []string) error {
+	fs := newFlags("locations update")
+	short := fs.String("short", "", "short location code")
+	long := fs.String("long", "", "location description")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	set := changed(fs)
+	if len(set) == 0 {
+		return usageErrorf("locations update requires -short or -long")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	location, err := app.GetLocation(id)
+	if err != nil {
+		return err
+	}
+	if set["short"] {
+		location.Short = *short
+	}
+	if set["long"] {
+		location.Long = *long
+	}
+
+	location, err = app.UpdateLocation(id, location.Short, location.Long)
+	if err != nil {
+		return err
+	}
+
+	return c.print(location, locationsTable(location))
+}
+
+func (c *cli) deleteLocation(args []string) error {
+	fs := newFlags("locations delete")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.DeleteLocation(id); err != nil {
+		return err
+	}
+
+	c.message("deleted location %d", id)
+	return nil
+}
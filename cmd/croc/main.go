@@ -0,0 +1,255 @@
+// Command croc manages a Pterodactyl panel from the command line using the
+// application API.
+//
+//	croc [-profile NAME] [-url URL] [-key KEY] [-o table|json|yaml] <resource> <action> [flags] [args]
+//
+// Resources are users, nodes, allocations, locations and servers. The client
+// resource works with a single server through the client API instead:
+//
+//	croc client servers
+//	croc client console <server>
+//	croc client power start|stop|restart|kill <server>
+//	croc client resources [-watch 2s] <server>
+//	croc client files ls|cat|get|put|rm|mv|chmod <server> ...
+//
+// croc panel probe [-client] checks the connection and key, and shows the
+// flavor, version and capabilities of the panel.
+//
+// The panel URL and keys come from a profile in the config file given with
+// -config or CROC_CONFIG, see crocgodyl.LoadConfig. The environment overrides
+// the profile (CROC_PANEL_URL, CROC_APP_KEY, CROC_CLIENT_KEY, ...) and flags
+// override both. Keys may be key sources such as file:PATH or env:NAME. For
+// compatibility CROC_URL and CROC_KEY are used when nothing else sets the URL
+// or application key.
+//
+// The exit code describes what went wrong: 1 for any other error, 2 for
+// invalid usage, 3 when the key is not authorized, 4 when a resource was not
+// found, 5 when the panel rejected the request data, 6 on a conflict, 7 when
+// rate limited and 8 when the panel had an internal error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+type cli struct {
+	in        io.Reader
+	out       io.Writer
+	errOut    io.Writer
+	format    string
+	config    string
+	profile   string
+	url       string
+	key       string
+	clientKey string
+	debug     bool
+	responses *croc.ResponseLog
+	app       *croc.Application
+	client    *croc.Client
+}
+
+type command struct {
+	name    string
+	usage   string
+	actions map[string]func(args []string) error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{in: stdin, out: stdout, errOut: stderr}
+
+	global := flag.NewFlagSet("croc", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.StringVar(&c.config, "config", "", "config file, defaults to CROC_CONFIG or croc/config.yml in the user config directory")
+	global.StringVar(&c.profile, "profile", "", "profile to use, defaults to CROC_PROFILE or the default profile")
+	global.StringVar(&c.url, "url", "", "panel url, overrides the profile")
+	global.StringVar(&c.key, "key", "", "application api key or key source, overrides the profile")
+	global.StringVar(&c.clientKey, "client-key", "", "client api key or key source, overrides the profile")
+	global.StringVar(&c.format, "o", "table", "output format: table, json or yaml")
+	global.BoolVar(&c.debug, "debug", false, "print every panel response to stderr")
+	global.Usage = func() {
+		fmt.Fprintln(stderr, "usage: croc [flags] <resource> <action> [flags] [args]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "resources:")
+		for _, cmd := range c.commands() {
+			fmt.Fprintf(stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+		}
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "flags:")
+		global.PrintDefaults()
+	}
+
+	if err := global.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return exitUsage
+	}
+
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+
+	switch c.format {
+	case "table", "json", "yaml":
+	default:
+		fmt.Fprintf(stderr, "croc: unknown output format %q\n", c.format)
+		return exitUsage
+	}
+
+	if c.debug {
+		c.responses = &croc.ResponseLog{MaxBody: 4096}
+	}
+
+	err := c.dispatch(global.Args())
+	c.printResponses()
+	if err == nil {
+		return 0
+	}
+
+	printError(stderr, err)
+	return exitCode(err)
+}
+
+func (c *cli) commands() []*command {
+	return []*command{
+		c.usersCommand(),
+		c.nodesCommand(),
+		c.allocationsCommand(),
+		c.locationsCommand(),
+		c.serversCommand(),
+		c.clientCommand(),
+		c.panelCommand(),
+	}
+}
+
+func (c *cli) dispatch(args []string) error {
+	for _, cmd := range c.commands() {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		return subcommand(cmd.name, cmd.usage, cmd.actions, args[1:])
+	}
+
+	return usageErrorf("unknown resource %q", args[0])
+}
+
+// subcommand runs the action named by the first argument, it is also used for
+// nested actions such as "client files ls".
+func subcommand(name, usage string, actions map[string]func([]string) error, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("usage: croc %s %s", name, usage)
+	}
+
+	action, ok := actions[args[0]]
+	if !ok {
+		return usageErrorf("unknown %s action %q, expected %s", name, args[0], usage)
+	}
+
+	return action(args[1:])
+}
+
+// loadProfile resolves the profile and applies the global flags to it.
+func (c *cli) loadProfile() (*croc.Profile, error) {
+	cfg, err := croc.LoadConfig(c.config)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	p, err := cfg.Profile(c.profile)
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	for _, o := range []struct {
+		field    *string
+		flag     string
+		fallback string
+	}{
+		{&p.PanelURL, c.url, "CROC_URL"},
+		{&p.AppKey, c.key, "CROC_KEY"},
+		{&p.ClientKey, c.clientKey, ""},
+	} {
+		if o.flag != "" {
+			*o.field = o.flag
+		} else if *o.field == "" && o.fallback != "" {
+			*o.field = os.Getenv(o.fallback)
+		}
+	}
+
+	return p, nil
+}
+
+// application creates the api client once the command is known to be valid so
+// usage errors are reported before missing credentials.
+func (c *cli) application() (*croc.Application, error) {
+	if c.app != nil {
+		return c.app, nil
+	}
+
+	p, err := c.loadProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := p.Application(croc.WithUserAgent("croc"))
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	if c.responses != nil {
+		app = app.Capture(c.responses)
+	}
+
+	c.app = app
+	return app, nil
+}
+
+func (c *cli) clientAPI() (*croc.Client, error) {
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	p, err := c.loadProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := p.Client(croc.WithUserAgent("croc"))
+	if err != nil {
+		return nil, usageErrorf("%v", err)
+	}
+
+	if c.responses != nil {
+		client = client.Capture(c.responses)
+	}
+
+	c.client = client
+	return client, nil
+}
+
+// printResponses writes the responses recorded with -debug, bodies are only
+// shown for failed requests.
+func (c *cli) printResponses() {
+	if c.responses == nil {
+		return
+	}
+
+	for _, r := range c.responses.Responses() {
+		fmt.Fprintf(c.errOut, "debug: %s %s: %s in %s\n", r.Method, r.URL, r.Status, r.Duration.Round(time.Millisecond))
+		if r.StatusCode >= 400 && len(r.Body) > 0 {
+			fmt.Fprintf(c.errOut, "debug: %s\n", r.Body)
+		}
+	}
+}
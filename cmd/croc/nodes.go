@@ -0,0 +1,364 @@
+package main
+
+import (
+	"flag"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) nodesCommand() *command {
+	return &command{
+		name:  "nodes",
+		usage: "list|get|create|update|delete",
+		actions: map[string]func([]string) error{
+			"list":   c.listNodes,
+			"get":    c.getNode,
+			"create": c.createNode,
+			"update": c.updateNode,
+			"delete": c.deleteNode,
+		},
+	}
+}
+
+func nodesTable(nodes ...*croc.Node) *table {
+	t := &table{headers: []string{"ID", "NAME", "FQDN", "LOCATION", "MEMORY", "DISK", "PUBLIC", "MAINTENANCE"}}
+	for _, n := range nodes {
+		t.add(n.ID, n.Name, n.FQDN, n.LocationID, croc.MiB(n.Memory).String(), croc.MiB(n.Disk).String(), n.Public, n.MaintenanceMode)
+	}
+	return t
+}
+
+func (c *cli) listNodes(args []string) error {
+	fs := newFlags("nodes list")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := app.GetNodes()
+	if err != nil {
+		return err
+	}
+
+	return c.print(nodes, nodesTable(nodes...))
+}
+
+func (c *cli) getNode(args []string) error {
+	fs := newFlags("nodes get")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	node, err := app.GetNode(id)
+	if err != nil {
+		return err
+	}
+
+	return c.print(node, nodesTable(node))
+}
+
+type nodeFields struct {
+	croc.CreateNodeDescriptor
+	sftp   int
+	listen int
+}
+
+func (f *nodeFields) descriptor() croc.CreateNodeDescriptor {
+	d := f.CreateNodeDescriptor
+	d.DaemonSftp = int32(f.sftp)
+	d.DaemonListen = int32(f.listen)
+	return d
+}
+
+func nodeFlags(name string) (*flag.FlagSet, *nodeFields) {
+	fs := newFlags(name)
+	f := &nodeFields{}
+	fs.StringVar(&f.Name, "name", "", "node name")
+	fs.StringVar(&f.Description, "description", "", "node description")
+	fs.IntVar(&f.LocationID, "location", 0, "location id")
+	fs.BoolVar(&f.Public, "public", true, "allow automatic allocation to this node")
+	fs.StringVar(&f.FQDN, "fqdn", "", "domain name or ip of the node")
+	fs.StringVar(&f.Scheme, "scheme", "https", "scheme used to connect to the daemon")
+	fs.BoolVar(&f.BehindProxy, "behind-proxy", false, "the daemon is behind a proxy")
+	fs.Var(&unitValue{&f.Memory, parseMiB, formatMiB}, "memory", "total memory such as 64GiB")
+	fs.Int64Var(&f.MemoryOverallocate, "memory-overallocate", 0, "memory overallocation in percent")
+	fs.Var(&unitValue{&f.Disk, parseMiB, formatMiB}, "disk", "total disk space such as 1TiB")
+	fs.Int64Var(&f.DiskOverallocate, "disk-overallocate", 0, "disk overallocation in percent")
+	fs.StringVar(&f.DaemonBase, "daemon-base", "/var/lib/pterodactyl/volumes", "directory server files are stored in")
+	fs.IntVar(&f.sftp, "daemon-sftp", 2022, "daemon sftp port")
+	fs.IntVar(&f.listen, "daemon-listen", 8080, "daemon api port")
+	fs.Int64Var(&f.UploadSize, "upload-size", 100, "maximum upload size in MiB")
+
+	return fs, f
+}
+
+func (c *cli) createNode(args []string) error {
+	fs, f := nodeFlags("nodes create")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if f.Name == "" || f.FQDN == "" || f.LocationID == 0 || f.Memory == 0 || f.Disk == 0 {
+		return usageErrorf("nodes create requires -name, -fqdn, -location, -memory and -disk")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	node, err := app.CreateNode(f.descriptor())
+	if err != nil {
+		return err
+	}
+
+	return c.print(node, nodesTable(node))
+}
+
+func (c *cli) updateNode(args []string) error {
+	fs, f := nodeFlags("nodes update")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	set := changed(fs)
+	if len(set) == 0 {
+		return usageErrorf("nodes update requires at least one field to change")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	node, err := app.GetNode(id)
+	if err != nil {
+		return err
+	}
+
+	d := f.descriptor()
+	desired := *node
+	if set["name"] {
+		desired.Name = d.Name
+	}
+	if set["description"] {
+		desired.Description = d.Description
+	}
+	if set["location"] {
+		desired.LocationID = d.LocationID
+	}
+	if set["public"] {
+		desired.Public = d.Public
+	}
+	if set["fqdn"] {
+		desired.FQDN = d.FQDN
+	}
+	if set["scheme"] {
+		desired.Scheme = d.Scheme
+	}
+	if set["behind-proxy"] {
+		desired.BehindProxy = d.BehindProxy
+	}
+	if set["memory"] {
+		desired.Memory = d.Memory
+	}
+	if set["memory-overallocate"] {
+		desired.MemoryOverallocate = d.MemoryOverallocate
+	}
+	if set["disk"] {
+		desired.Disk = d.Disk
+	}
+	if set["disk-overallocate"] {
+		desired.DiskOverallocate = d.DiskOverallocate
+	}
+	if set["daemon-base"] {
+		desired.DaemonBase = d.DaemonBase
+	}
+	if set["daemon-sftp"] {
+		desired.DaemonSftp = d.DaemonSftp
+	}
+	if set["daemon-listen"] {
+		desired.DaemonListen = d.DaemonListen
+	}
+	if set["upload-size"] {
+		desired.UploadSize = d.UploadSize
+	}
+
+	if err = desired.ValidateResources(); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	update, diff := node.DiffUpdateDescriptor(&desired)
+	if !diff {
+		return c.print(node, nodesTable(node))
+	}
+
+	node, err = app.UpdateNode(id, *update)
+	if err != nil {
+		return err
+	}
+
+	return c.print(node, nodesTable(node))
+}
+
+func (c *cli) deleteNode(args []string) error {
+	fs := newFlags("nodes delete")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.DeleteNode(id); err != nil {
+		return err
+	}
+
+	c.message("deleted node %d", id)
+	return nil
+}
+
+func (c *cli) allocationsCommand() *command {
+	return &command{
+		name:  "allocations",
+		usage: "list|create|delete",
+		actions: map[string]func([]string) error{
+			"list":   c.listAllocations,
+			"create": c.createAllocations,
+			"delete": c.deleteAllocation,
+		},
+	}
+}
+
+func allocationsTable(allocs ...*croc.Allocation) *table {
+	t := &table{headers: []string{"ID", "IP", "PORT", "ALIAS", "ASSIGNED", "NOTES"}}
+	for _, a := range allocs {
+		t.add(a.ID, a.IP, a.Port, a.Alias, a.Assigned, a.Notes)
+	}
+	return t
+}
+
+func nodeFlag(fs *flag.FlagSet) *int {
+	return fs.Int("node", 0, "node id")
+}
+
+func (c *cli) listAllocations(args []string) error {
+	fs := newFlags("allocations list")
+	node := nodeFlag(fs)
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if *node <= 0 {
+		return usageErrorf("allocations list requires -node")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	allocs, err := app.GetNodeAllocations(*node)
+	if err != nil {
+		return err
+	}
+
+	return c.print(allocs, allocationsTable(allocs...))
+}
+
+func (c *cli) createAllocations(args []string) error {
+	fs := newFlags("allocations create")
+	node := nodeFlag(fs)
+	d := croc.CreateAllocationsDescriptor{}
+	fs.StringVar(&d.IP, "ip", "", "ip address to allocate")
+	fs.StringVar(&d.Alias, "alias", "", "ip alias shown to users")
+	ports := stringList{}
+	fs.Var(&ports, "ports", "comma separated ports or ranges such as 25565,25570-25580")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if *node <= 0 || d.IP == "" || len(ports) == 0 {
+		return usageErrorf("allocations create requires -node, -ip and -ports")
+	}
+	d.Ports = ports
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.CreateNodeAllocations(*node, d); err != nil {
+		return err
+	}
+
+	c.message("created allocations on node %d", *node)
+	return nil
+}
+
+func (c *cli) deleteAllocation(args []string) error {
+	fs := newFlags("allocations delete")
+	node := nodeFlag(fs)
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+	if *node <= 0 {
+		return usageErrorf("allocations delete requires -node")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.DeleteNodeAllocation(*node, id); err != nil {
+		return err
+	}
+
+	c.message("deleted allocation %d", id)
+	return nil
+}
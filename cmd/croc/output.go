@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type table struct {
+	headers []string
+	rows    [][]string
+}
+
+func (t *table) add(cols ...interface{}) {
+	row := make([]string, 0, len(cols))
+	for _, col := range cols {
+		row = append(row, formatCell(col))
+	}
+	t.rows = append(t.rows, row)
+}
+
+func formatCell(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		if val == "" {
+			return "-"
+		}
+		return val
+	case bool:
+		if val {
+			return "yes"
+		}
+		return "no"
+	case *time.Time:
+		if val == nil {
+			return "-"
+		}
+		return val.Local().Format("2006-01-02 15:04")
+	case int:
+		return strconv.Itoa(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// print writes v in the selected output format. Tables only show the columns
+// that fit on a terminal, JSON and YAML show the full model as the panel
+// returned it.
+func (c *cli) print(v interface{}, t *table) error {
+	switch c.format {
+	case "json":
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case "yaml":
+		// going through json keeps the field names of the json tags, which
+		// yaml also reads in the original order into a node
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		var node yaml.Node
+		if err = yaml.Unmarshal(buf, &node); err != nil {
+			return err
+		}
+		blockStyle(&node)
+
+		enc := yaml.NewEncoder(c.out)
+		enc.SetIndent(2)
+		if err = enc.Encode(&node); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	default:
+		w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.headers, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// blockStyle drops the flow and quoting style the json input left on the
+// nodes, the encoder still quotes strings that would be read as another type.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// message prints a confirmation for actions that do not return a model, it is
+// left out of json and yaml output so that stays parseable.
+func (c *cli) message(format string, args ...interface{}) {
+	if c.format == "table" {
+		fmt.Fprintf(c.out, format+"\n", args...)
+	}
+}
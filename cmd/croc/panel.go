@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) panelCommand() *command {
+	return &command{
+		name:  "panel",
+		usage: "probe",
+		actions: map[string]func([]string) error{
+			"probe": c.probePanel,
+		},
+	}
+}
+
+// probePanel checks the connection and key, and shows what kind of panel the
+// url points to.
+func (c *cli) probePanel(args []string) error {
+	fs := newFlags("panel probe")
+	client := fs.Bool("client", false, "probe with the client key instead of the application key, which can also narrow down the version")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	var info *croc.PanelInfo
+	if *client {
+		api, err := c.clientAPI()
+		if err != nil {
+			return err
+		}
+		if info, err = api.Probe(); err != nil {
+			return err
+		}
+	} else {
+		app, err := c.application()
+		if err != nil {
+			return err
+		}
+		if info, err = app.Probe(); err != nil {
+			return err
+		}
+	}
+
+	capabilities := make([]string, 0, len(info.Capabilities))
+	for _, capability := range info.Capabilities.List() {
+		capabilities = append(capabilities, string(capability))
+	}
+
+	t := &table{headers: []string{"FLAVOR", "VERSION", "LATENCY", "CAPABILITIES"}}
+	t.add(string(info.Flavor), info.Version.String(), info.Latency.Round(time.Millisecond).String(), strings.Join(capabilities, ","))
+
+	return c.print(info, t)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"flag"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) serversCommand() *command {
+	return &command{
+		name:  "servers",
+		usage: "list|get|create|update|delete|suspend|unsuspend",
+		actions: map[string]func([]string) error{
+			"list":      c.listServers,
+			"get":       c.getServer,
+			"create":    c.createServer,
+			"update":    c.updateServer,
+			"delete":    c.deleteServer,
+			"suspend":   c.suspendServer,
+			"unsuspend": c.unsuspendServer,
+		},
+	}
+}
+
+func serversTable(servers ...*croc.AppServer) *table {
+	t := &table{headers: []string{"ID", "IDENTIFIER", "NAME", "NODE", "USER", "STATUS", "SUSPENDED"}}
+	for _, s := range servers {
+		t.add(s.ID, s.Identifier, s.Name, s.Node, s.User, s.Status.String(), s.Suspended)
+	}
+	return t
+}
+
+func (c *cli) listServers(args []string) error {
+	fs := newFlags("servers list")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	servers, err := app.GetServers()
+	if err != nil {
+		return err
+	}
+
+	return c.print(servers, serversTable(servers...))
+}
+
+func (c *cli) getServer(args []string) error {
+	fs := newFlags("servers get")
+	external := fs.String("external", "", "look the server up by external id instead")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id := 0
+	if *external != "" {
+		err = noArgs(fs.Name(), args)
+	} else {
+		id, err = parseID(fs.Name(), args)
+	}
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	var server *croc.AppServer
+	if *external != "" {
+		server, err = app.GetServerExternal(*external)
+	} else {
+		server, err = app.GetServer(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.print(server, serversTable(server))
+}
+
+// serverFields holds every server flag, create uses all of them while update
+// sends only the fields that differ from the server.
+type serverFields struct {
+	limits  croc.Limits
+	feature croc.FeatureLimits
+	env     keyValues
+
+	name        string
+	description string
+	externalID  string
+	user        int
+	startup     string
+	image       string
+	egg         int
+	skipScripts bool
+	allocation  int
+	additional  intList
+	add         intList
+	remove      intList
+	locations   intList
+	dedicated   bool
+	portRange   stringList
+	start       bool
+}
+
+var (
+	detailsFlags = []string{"name", "description", "external-id", "user"}
+	buildFlags   = []string{"memory", "swap", "disk", "io", "cpu", "threads", "oom-disabled",
+		"allocation", "add-allocations", "remove-allocations", "databases", "backups", "allocations-limit"}
+	startupFlags = []string{"startup", "image", "egg", "env", "skip-scripts"}
+)
+
+func serverFlags(name string) (*flag.FlagSet, *serverFields) {
+	fs := newFlags(name)
+	f := &serverFields{env: keyValues{}}
+
+	fs.StringVar(&f.name, "name", "", "server name")
+	fs.StringVar(&f.description, "description", "", "server description")
+	fs.StringVar(&f.externalID, "external-id", "", "external id")
+	fs.IntVar(&f.user, "user", 0, "id of the owning user")
+
+	fs.Var(&unitValue{&f.limits.Memory, croc.ParseMemory, croc.FormatMemory}, "memory", "memory limit such as 4GiB, 0 for unlimited")
+	fs.Var(&unitValue{&f.limits.Swap, croc.ParseSwap, croc.FormatSwap}, "swap", "swap limit such as 512MiB, -1 for unlimited")
+	fs.Var(&unitValue{&f.limits.Disk, croc.ParseMemory, croc.FormatMemory}, "disk", "disk limit such as 10GiB, 0 for unlimited")
+	fs.Int64Var(&f.limits.IO, "io", 500, "block io weight")
+	fs.Var(&unitValue{&f.limits.CPU, croc.ParseCPU, croc.FormatCPU}, "cpu", "cpu limit such as 150%, 0 for unlimited")
+	fs.Var(&threadsValue{&f.limits.Threads}, "threads", "cpu threads to pin the server to, such as 0-3,6")
+	fs.BoolVar(&f.limits.OOMDisabled, "oom-disabled", false, "disable the out of memory killer")
+
+	fs.IntVar(&f.feature.Databases, "databases", 0, "database limit")
+	fs.IntVar(&f.feature.Backups, "backups", 0, "backup limit")
+	fs.IntVar(&f.feature.Allocations, "allocations-limit", 0, "allocation limit")
+
+	fs.StringVar(&f.startup, "startup", "", "startup command")
+	fs.StringVar(&f.image, "image", "", "docker image")
+	fs.IntVar(&f.egg, "egg", 0, "egg id")
+	fs.Var(f.env, "env", "egg variable as KEY=VALUE, can be repeated")
+	fs.BoolVar(&f.skipScripts, "skip-scripts", false, "skip the egg install script")
+
+	fs.IntVar(&f.allocation, "allocation", 0, "default allocation id")
+	fs.Var(&f.additional, "additional-allocations", "additional allocation ids when creating")
+	fs.Var(&f.add, "add-allocations", "allocation ids to add")
+	fs.Var(&f.remove, "remove-allocations", "allocation ids to remove")
+	fs.Var(&f.locations, "locations", "location ids to deploy to instead of an allocation")
+	fs.BoolVar(&f.dedicated, "dedicated-ip", false, "deploy to a dedicated ip")
+	fs.Var(&f.portRange, "port-range", "ports or ranges to deploy to")
+	fs.BoolVar(&f.start, "start", false, "start the server once it is installed")
+
+	return fs, f
+}
+
+func (c *cli) createServer(args []string) error {
+	fs, f := serverFlags("servers create")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if f.name == "" || f.user == 0 || f.egg == 0 {
+		return usageErrorf("servers create requires -name, -user and -egg")
+	}
+	if f.allocation == 0 && len(f.locations) == 0 {
+		return usageErrorf("servers create requires -allocation or -locations")
+	}
+	if err = f.limits.Validate(); err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	d := croc.CreateServerDescriptor{
+		ExternalID:        f.externalID,
+		Name:              f.name,
+		Description:       f.description,
+		User:              f.user,
+		Egg:               f.egg,
+		DockerImage:       f.image,
+		Startup:           f.startup,
+		Environment:       f.env,
+		SkipScripts:       f.skipScripts,
+		OOMDisabled:       f.limits.OOMDisabled,
+		Limits:            &f.limits,
+		FeatureLimtis:     f.feature,
+		StartOnCompletion: f.start,
+	}
+	if f.allocation != 0 {
+		d.Allocation = &croc.AllocationDescriptor{Default: f.allocation, Additional: f.additional}
+	} else {
+		d.Deploy = &croc.DeployDescriptor{Locations: f.locations, DedicatedIP: f.dedicated, PortRange: f.portRange}
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	server, err := app.CreateServer(d)
+	if err != nil {
+		return err
+	}
+
+	return c.print(server, serversTable(server))
+}
+
+func (c *cli) updateServer(args []string) error {
+	fs, f := serverFlags("servers update")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	set := changed(fs)
+	if !changedAny(set, detailsFlags...) && !changedAny(set, buildFlags...) && !changedAny(set, startupFlags...) {
+		return usageErrorf("servers update requires at least one field to change")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	server, err := app.GetServer(id)
+	if err != nil {
+		return err
+	}
+
+	desired := *server
+	if set["name"] {
+		desired.Name = f.name
+	}
+	if set["description"] {
+		desired.Description = f.description
+	}
+	if set["external-id"] {
+		desired.ExternalID = f.externalID
+	}
+	if set["user"] {
+		desired.User = f.user
+	}
+
+	if details, diff := server.DiffDetailsDescriptor(&desired); diff {
+		if server, err = app.UpdateServerDetails(id, *details); err != nil {
+			return err
+		}
+	}
+
+	if set["memory"] {
+		desired.Limits.Memory = f.limits.Memory
+	}
+	if set["swap"] {
+		desired.Limits.Swap = f.limits.Swap
+	}
+	if set["disk"] {
+		desired.Limits.Disk = f.limits.Disk
+	}
+	if set["io"] {
+		desired.Limits.IO = f.limits.IO
+	}
+	if set["cpu"] {
+		desired.Limits.CPU = f.limits.CPU
+	}
+	if set["threads"] {
+		desired.Limits.Threads = f.limits.Threads
+	}
+	if set["oom-disabled"] {
+		desired.Limits.OOMDisabled = f.limits.OOMDisabled
+	}
+	if set["allocation"] {
+		desired.Allocation = f.allocation
+	}
+	if set["databases"] {
+		desired.FeatureLimits.Databases = f.feature.Databases
+	}
+	if set["backups"] {
+		desired.FeatureLimits.Backups = f.feature.Backups
+	}
+	if set["allocations-limit"] {
+		desired.FeatureLimits.Allocations = f.feature.Allocations
+	}
+
+	if err = desired.Limits.Validate(); err != nil && changedAny(set, buildFlags...) {
+		return usageErrorf("%v", err)
+	}
+
+	if build, diff := server.DiffBuildDescriptor(&desired); diff || len(f.add) > 0 || len(f.remove) > 0 {
+		build.AddAllocations = f.add
+		build.RemoveAllocations = f.remove
+		if server, err = app.UpdateServerBuild(id, *build); err != nil {
+			return err
+		}
+	}
+
+	if set["startup"] {
+		desired.Container.StartupCommand = f.startup
+	}
+	if set["image"] {
+		desired.Container.Image = f.image
+	}
+	if set["egg"] {
+		desired.Egg = f.egg
+	}
+	desired.Container.Environment = map[string]interface{}{}
+	for k, v := range server.Container.Environment {
+		desired.Container.Environment[k] = v
+	}
+	for k, v := range f.env {
+		desired.Container.Environment[k] = v
+	}
+
+	if startup, diff := server.DiffStartupDescriptor(&desired); diff {
+		startup.SkipScripts = f.skipScripts
+		if server, err = app.UpdateServerStartup(id, *startup); err != nil {
+			return err
+		}
+	}
+
+	return c.print(server, serversTable(server))
+}
+
+func (c *cli) deleteServer(args []string) error {
+	fs := newFlags("servers delete")
+	force := fs.Bool("force", false, "delete the server even if the daemon can not be reached")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.DeleteServer(id, *force); err != nil {
+		return err
+	}
+
+	c.message("deleted server %d", id)
+	return nil
+}
+
+func (c *cli) suspendServer(args []string) error {
+	fs := newFlags("servers suspend")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.SuspendServer(id); err != nil {
+		return err
+	}
+
+	c.message("suspended server %d", id)
+	return nil
+}
+
+func (c *cli) unsuspendServer(args []string) error {
+	fs := newFlags("servers unsuspend")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.UnsuspendServer(id); err != nil {
+		return err
+	}
+
+	c.message("unsuspended server %d", id)
+	return nil
+}
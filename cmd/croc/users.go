@@ -0,0 +1,219 @@
+package main
+
+import (
+	"flag"
+
+	croc "github.com/parkervcp/crocgodyl"
+)
+
+func (c *cli) usersCommand() *command {
+	return &command{
+		name:  "users",
+		usage: "list|get|create|update|delete",
+		actions: map[string]func([]string) error{
+			"list":   c.listUsers,
+			"get":    c.getUser,
+			"create": c.createUser,
+			"update": c.updateUser,
+			"delete": c.deleteUser,
+		},
+	}
+}
+
+func usersTable(users ...*croc.User) *table {
+	t := &table{headers: []string{"ID", "USERNAME", "EMAIL", "NAME", "ADMIN", "2FA", "EXTERNAL ID"}}
+	for _, u := range users {
+		t.add(u.ID, u.Username, u.Email, u.FullName(), u.RootAdmin, u.TwoFactor, u.ExternalID)
+	}
+	return t
+}
+
+func (c *cli) listUsers(args []string) error {
+	fs := newFlags("users list")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	users, err := app.GetUsers()
+	if err != nil {
+		return err
+	}
+
+	return c.print(users, usersTable(users...))
+}
+
+func (c *cli) getUser(args []string) error {
+	fs := newFlags("users get")
+	external := fs.String("external", "", "look the user up by external id instead")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+
+	id := 0
+	if *external != "" {
+		err = noArgs(fs.Name(), args)
+	} else {
+		id, err = parseID(fs.Name(), args)
+	}
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	var user *croc.User
+	if *external != "" {
+		user, err = app.GetUserExternal(*external)
+	} else {
+		user, err = app.GetUser(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.print(user, usersTable(user))
+}
+
+func userFlags(name string) (*flag.FlagSet, *croc.CreateUserDescriptor) {
+	fs := newFlags(name)
+	d := &croc.CreateUserDescriptor{}
+	fs.StringVar(&d.Email, "email", "", "email address")
+	fs.StringVar(&d.Username, "username", "", "username")
+	fs.StringVar(&d.FirstName, "first-name", "", "first name")
+	fs.StringVar(&d.LastName, "last-name", "", "last name")
+	fs.StringVar(&d.Password, "password", "", "password, a reset email is sent if not set")
+	fs.StringVar(&d.Language, "language", "", "language code")
+	fs.StringVar(&d.ExternalID, "external-id", "", "external id")
+	fs.BoolVar(&d.RootAdmin, "admin", false, "make the user a root admin")
+
+	return fs, d
+}
+
+func (c *cli) createUser(args []string) error {
+	fs, d := userFlags("users create")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err = noArgs(fs.Name(), args); err != nil {
+		return err
+	}
+	if d.Email == "" || d.Username == "" || d.FirstName == "" || d.LastName == "" {
+		return usageErrorf("users create requires -email, -username, -first-name and -last-name")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := app.CreateUser(*d)
+	if err != nil {
+		return err
+	}
+
+	return c.print(user, usersTable(user))
+}
+
+func (c *cli) updateUser(args []string) error {
+	fs, d := userFlags("users update")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	set := changed(fs)
+	if len(set) == 0 {
+		return usageErrorf("users update requires at least one field to change")
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	user, err := app.GetUser(id)
+	if err != nil {
+		return err
+	}
+
+	desired := *user
+	if set["email"] {
+		desired.Email = d.Email
+	}
+	if set["username"] {
+		desired.Username = d.Username
+	}
+	if set["first-name"] {
+		desired.FirstName = d.FirstName
+	}
+	if set["last-name"] {
+		desired.LastName = d.LastName
+	}
+	if set["language"] {
+		desired.Language = d.Language
+	}
+	if set["external-id"] {
+		desired.ExternalID = d.ExternalID
+	}
+	if set["admin"] {
+		desired.RootAdmin = d.RootAdmin
+	}
+
+	update, diff := user.DiffUpdateDescriptor(&desired)
+	if set["password"] {
+		update.Password = croc.String(d.Password)
+		diff = true
+	}
+	if !diff {
+		return c.print(user, usersTable(user))
+	}
+
+	user, err = app.UpdateUser(id, *update)
+	if err != nil {
+		return err
+	}
+
+	return c.print(user, usersTable(user))
+}
+
+func (c *cli) deleteUser(args []string) error {
+	fs := newFlags("users delete")
+	args, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(fs.Name(), args)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.application()
+	if err != nil {
+		return err
+	}
+
+	if err = app.DeleteUser(id); err != nil {
+		return err
+	}
+
+	c.message("deleted user %d", id)
+	return nil
+}
@@ -0,0 +1,1195 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigParser string
+
+const (
+	ParserProperties ConfigParser = "properties"
+	ParserINI        ConfigParser = "ini"
+	ParserYAML       ConfigParser = "yaml"
+	ParserJSON       ConfigParser = "json"
+	ParserTOML       ConfigParser = "toml"
+	ParserXML        ConfigParser = "xml"
+)
+
+// ConfigParserFor guesses the parser for a file from its extension.
+func ConfigParserFor(name string) (ConfigParser, bool) {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".properties":
+		return ParserProperties, true
+	case ".ini", ".cfg", ".conf":
+		return ParserINI, true
+	case ".yml", ".yaml":
+		return ParserYAML, true
+	case ".json":
+		return ParserJSON, true
+	case ".toml":
+		return ParserTOML, true
+	case ".xml":
+		return ParserXML, true
+	default:
+		return "", false
+	}
+}
+
+func (c *Client) EditServerConfig(identifier, file string, parser ConfigParser, edits map[string]interface{}) error {
+	data, err := c.GetServerFileContents(identifier, file)
+	if err != nil {
+		return err
+	}
+
+	out, err := EditConfig(data, parser, edits)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(data, out) {
+		return nil
+	}
+
+	return c.WriteServerFile(identifier, file, string(out))
+}
+
+// EditConfig sets the values of keys in a config file while leaving the rest
+// of the file, including comments, untouched where the format allows it.
+//
+// Keys are paths separated by dots, with "\." for a literal dot. A segment can
+// be "*" or a glob to match any key at that level, and for XML a final segment
+// starting with "@" sets an attribute. Properties keys are matched as a whole.
+// Keys that do not exist are created unless they contain a wildcard, except
+// XML attributes which need an existing element.
+func EditConfig(data []byte, parser ConfigParser, edits map[string]interface{}) ([]byte, error) {
+	switch parser {
+	case ParserProperties:
+		return editProperties(data, edits)
+	case ParserINI:
+		return editINI(data, edits)
+	case ParserYAML:
+		return editYAML(data, edits)
+	case ParserJSON:
+		return editJSON(data, edits)
+	case ParserTOML:
+		return editTOML(data, edits)
+	case ParserXML:
+		return editXML(data, edits)
+	default:
+		return nil, fmt.Errorf("unsupported config parser %q", parser)
+	}
+}
+
+func splitKeyPath(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key) && key[i+1] == '.':
+			cur.WriteByte('.')
+			i++
+		case key[i] == '.':
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(key[i])
+		}
+	}
+
+	return append(parts, cur.String())
+}
+
+func isWildcard(segment string) bool {
+	return strings.ContainsAny(segment, "*?[")
+}
+
+func hasWildcard(parts []string) bool {
+	for _, p := range parts {
+		if isWildcard(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegment(pattern, segment string) bool {
+	if pattern == segment {
+		return true
+	}
+	ok, _ := path.Match(pattern, segment)
+	return ok
+}
+
+func matchKeyPath(pattern, parts []string) bool {
+	if len(pattern) != len(parts) {
+		return false
+	}
+	for i := range pattern {
+		if !matchSegment(pattern[i], parts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedEdits returns the keys in a stable order so repeated runs produce the
+// same output when new keys are appended. Exact keys come before wildcards so
+// they take precedence when both match the same entry.
+func sortedEdits(edits map[string]interface{}) []string {
+	keys := make([]string, 0, len(edits))
+	for k := range edits {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		wi, wj := isWildcard(keys[i]), isWildcard(keys[j])
+		if wi != wj {
+			return wj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func plainValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case fmt.Stringer:
+		return val.String()
+	case []byte:
+		return string(val)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(val)
+	default:
+		buf, _ := json.Marshal(val)
+		return string(buf)
+	}
+}
+
+func splitLines(data []byte) ([]string, string) {
+	text := string(data)
+	eol := "\n"
+	if strings.Contains(text, "\r\n") {
+		eol = "\r\n"
+	}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines, eol
+}
+
+func joinLines(lines []string, eol string) []byte {
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, eol) + eol)
+}
+
+func insertLine(lines []string, at int, line string) []string {
+	lines = append(lines, "")
+	copy(lines[at+1:], lines[at:])
+	lines[at] = line
+	return lines
+}
+
+var propertiesLine = regexp.MustCompile(`^(\s*)((?:\\.|[^\s=:\\])+)(\s*[=:]\s*|\s+)?(.*)$`)
+
+func editProperties(data []byte, edits map[string]interface{}) ([]byte, error) {
+	lines, eol := splitLines(data)
+	found := map[string]bool{}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			continue
+		}
+
+		m := propertiesLine.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+
+		// values can be continued on the next line with a trailing backslash
+		end := i
+		for end < len(lines)-1 && strings.HasSuffix(lines[end], "\\") && !strings.HasSuffix(lines[end], "\\\\") {
+			end++
+		}
+
+		key := strings.ReplaceAll(m[2], "\\", "")
+		for _, pattern := range sortedEdits(edits) {
+			if !matchSegment(pattern, key) {
+				continue
+			}
+
+			sep := m[3]
+			if sep == "" {
+				sep = "="
+			}
+			lines[i] = m[1] + m[2] + sep + escapeProperty(plainValue(edits[pattern]))
+			lines = append(lines[:i+1], lines[end+1:]...)
+			end = i
+			found[pattern] = true
+			break
+		}
+		i = end
+	}
+
+	for _, key := range sortedEdits(edits) {
+		if !found[key] && !isWildcard(key) {
+			lines = append(lines, key+"="+escapeProperty(plainValue(edits[key])))
+		}
+	}
+
+	return joinLines(lines, eol), nil
+}
+
+func escapeProperty(v string) string {
+	v = strings.ReplaceAll(v, "\\", "\\\\")
+	v = strings.ReplaceAll(v, "\n", "\\n")
+	if strings.HasPrefix(v, " ") {
+		v = "\\" + v
+	}
+	return v
+}
+
+var (
+	iniSection = regexp.MustCompile(`^\s*\[([^\]]*)\]`)
+	iniKey     = regexp.MustCompile(`^(\s*)([^=:;#\s][^=:]*?)(\s*[=:]\s*)(.*)$`)
+)
+
+func editINI(data []byte, edits map[string]interface{}) ([]byte, error) {
+	lines, eol := splitLines(data)
+	found := map[string]bool{}
+
+	// index of the last key, or header, line of every section so new keys
+	// can be added to the end of it
+	last := map[string]int{"": -1}
+	section := ""
+
+	for i, line := range lines {
+		if m := iniSection.FindStringSubmatch(line); m != nil {
+			section = strings.TrimSpace(m[1])
+			last[section] = i
+			continue
+		}
+
+		m := iniKey.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		last[section] = i
+
+		parts := []string{section, strings.TrimSpace(m[2])}
+		for _, key := range sortedEdits(edits) {
+			pattern := iniPath(key)
+			if matchKeyPath(pattern, parts) {
+				lines[i] = m[1] + m[2] + m[3] + plainValue(edits[key]) + iniComment(m[4])
+				found[key] = true
+				break
+			}
+		}
+	}
+
+	for _, key := range sortedEdits(edits) {
+		if found[key] || isWildcard(key) {
+			continue
+		}
+
+		pattern := iniPath(key)
+		line := pattern[1] + " = " + plainValue(edits[key])
+		at, ok := last[pattern[0]]
+		if !ok {
+			lines = append(lines, "", "["+pattern[0]+"]")
+			at = len(lines) - 1
+		}
+
+		lines = insertLine(lines, at+1, line)
+		for s, idx := range last {
+			if idx > at {
+				last[s] = idx + 1
+			}
+		}
+		last[pattern[0]] = at + 1
+	}
+
+	return joinLines(lines, eol), nil
+}
+
+// iniComment returns the inline comment after a value, if there is one.
+func iniComment(value string) string {
+	for _, marker := range []string{" ;", " #", "\t;", "\t#"} {
+		if i := strings.Index(value, marker); i >= 0 {
+			return value[i:]
+		}
+	}
+	return ""
+}
+
+// iniPath splits a key into its section and name, keys without a section
+// belong to the global section at the top of the file.
+func iniPath(key string) []string {
+	parts := splitKeyPath(key)
+	if len(parts) == 1 {
+		return []string{"", parts[0]}
+	}
+	return []string{strings.Join(parts[:len(parts)-1], "."), parts[len(parts)-1]}
+}
+
+var (
+	tomlTable = regexp.MustCompile(`^\s*\[\[?\s*([^\]]*?)\s*\]\]?`)
+	tomlKey   = regexp.MustCompile(`^(\s*)((?:"[^"]*"|'[^']*'|[A-Za-z0-9_\-.\s])+?)(\s*=\s*)(.*)$`)
+)
+
+func splitTOMLKey(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		switch {
+		case quote != 0 && ch == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteByte(ch)
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '.':
+			parts = append(parts, strings.TrimSpace(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteByte(ch)
+		}
+	}
+
+	return append(parts, strings.TrimSpace(cur.String()))
+}
+
+// tomlValueEnd returns the line and column where the value starting at col
+// of line i ends, so trailing comments can be kept when it is replaced.
+// Arrays, inline tables and multi-line strings continue on the lines below.
+func tomlValueEnd(lines []string, i, col int) (int, int, error) {
+	depth := 0
+	for ; i < len(lines); i, col = i+1, 0 {
+		s := lines[i]
+		for col < len(s) {
+			switch c := s[col]; {
+			case strings.HasPrefix(s[col:], `"""`) || strings.HasPrefix(s[col:], "'''"):
+				var err error
+				if i, col, err = tomlMultilineEnd(lines, i, col); err != nil {
+					return 0, 0, err
+				}
+				s = lines[i]
+				if depth == 0 {
+					return i, col, nil
+				}
+
+			case c == '"' || c == '\'':
+				end := col + 1
+				for ; end < len(s) && s[end] != c; end++ {
+					if c == '"' && s[end] == '\\' {
+						end++
+					}
+				}
+				if end >= len(s) {
+					return 0, 0, fmt.Errorf("invalid toml on line %d: unterminated string", i+1)
+				}
+				col = end + 1
+				if depth == 0 {
+					return i, col, nil
+				}
+
+			case c == '#':
+				if depth == 0 {
+					return i, len(strings.TrimRight(s[:col], " \t")), nil
+				}
+				col = len(s)
+
+			case c == '[' || c == '{':
+				depth++
+				col++
+
+			case c == ']' || c == '}':
+				depth--
+				col++
+				if depth == 0 {
+					return i, col, nil
+				}
+
+			default:
+				col++
+			}
+		}
+
+		if depth == 0 {
+			return i, len(strings.TrimRight(s, " \t")), nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("invalid toml on line %d: unterminated array or table", len(lines))
+}
+
+// tomlMultilineEnd returns the position after the closing delimiter of the
+// multi-line string starting at col of line i.
+func tomlMultilineEnd(lines []string, i, col int) (int, int, error) {
+	delim := lines[i][col : col+3]
+	start := i
+	for col += 3; i < len(lines); i, col = i+1, 0 {
+		s := lines[i]
+		for ; col < len(s); col++ {
+			if delim[0] == '"' && s[col] == '\\' {
+				col++
+				continue
+			}
+			if strings.HasPrefix(s[col:], delim) {
+				// up to two quotes before the delimiter belong to the string
+				end := col + 3
+				for n := 0; n < 2 && end < len(s) && s[end] == delim[0]; n++ {
+					end++
+				}
+				return i, end, nil
+			}
+		}
+	}
+
+	return 0, 0, fmt.Errorf("invalid toml on line %d: unterminated string", start+1)
+}
+
+func tomlValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case nil:
+		return `""`
+	default:
+		return plainValue(val)
+	}
+}
+
+func editTOML(data []byte, edits map[string]interface{}) ([]byte, error) {
+	lines, eol := splitLines(data)
+	found := map[string]bool{}
+	last := map[string]int{"": -1}
+	table := []string(nil)
+	tableName := ""
+
+	// values are copied to out, so the lines a replaced value continued on
+	// are dropped
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := tomlTable.FindStringSubmatch(line); m != nil && !strings.Contains(line[:strings.Index(line, "[")], "=") {
+			table = splitTOMLKey(m[1])
+			tableName = strings.Join(table, ".")
+			out = append(out, line)
+			last[tableName] = len(out) - 1
+			continue
+		}
+
+		m := tomlKey.FindStringSubmatch(line)
+		if m == nil || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			out = append(out, line)
+			continue
+		}
+
+		end, col, err := tomlValueEnd(lines, i, len(line)-len(m[4]))
+		if err != nil {
+			return nil, err
+		}
+
+		parts := append(append([]string{}, table...), splitTOMLKey(m[2])...)
+		replaced := false
+		for _, key := range sortedEdits(edits) {
+			if matchKeyPath(splitKeyPath(key), parts) {
+				out = append(out, m[1]+m[2]+m[3]+tomlValue(edits[key])+lines[end][col:])
+				found[key] = true
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, lines[i:end+1]...)
+		}
+
+		last[tableName] = len(out) - 1
+		i = end
+	}
+	lines = out
+
+	for _, key := range sortedEdits(edits) {
+		if found[key] || isWildcard(key) {
+			continue
+		}
+
+		parts := splitKeyPath(key)
+		name := parts[len(parts)-1]
+		if strings.ContainsAny(name, " .\"'") {
+			name = strconv.Quote(name)
+		}
+		parent := strings.Join(parts[:len(parts)-1], ".")
+
+		// a key belongs to the top level table if it is not in a header
+		line := name + " = " + tomlValue(edits[key])
+		at, ok := last[parent]
+		if !ok {
+			lines = append(lines, "", "["+parent+"]")
+			at = len(lines) - 1
+		}
+
+		lines = insertLine(lines, at+1, line)
+		for t, idx := range last {
+			if idx > at {
+				last[t] = idx + 1
+			}
+		}
+		last[parent] = at + 1
+	}
+
+	return joinLines(lines, eol), nil
+}
+
+func editYAML(data []byte, edits map[string]interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+
+	// wildcards are applied first so exact keys overwrite what they set
+	keys := sortedEdits(edits)
+	for i := len(keys) - 1; i >= 0; i-- {
+		key := keys[i]
+		parts := splitKeyPath(key)
+		matched, err := setYAML(doc.Content[0], parts, edits[key], !hasWildcard(parts))
+		if err != nil {
+			return nil, fmt.Errorf("config key %q could not be set, %v", key, err)
+		}
+		if !matched && !hasWildcard(parts) {
+			return nil, fmt.Errorf("config key %q could not be set", key)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	enc.Close()
+
+	return buf.Bytes(), nil
+}
+
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if n := len(line) - len(trimmed); n > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "- ") {
+			return n
+		}
+	}
+	return 2
+}
+
+func setYAML(node *yaml.Node, parts []string, value interface{}, create bool) (bool, error) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	matched := false
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if !matchSegment(parts[0], node.Content[i].Value) {
+				continue
+			}
+
+			ok, err := setYAMLValue(node, i+1, parts, value, create)
+			if err != nil {
+				return false, err
+			}
+			matched = matched || ok
+		}
+
+		if !matched && create {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: parts[0]}
+			valueNode := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, keyNode, valueNode)
+			return setYAMLValue(node, len(node.Content)-1, parts, value, create)
+		}
+
+	case yaml.SequenceNode:
+		for i := range node.Content {
+			if !matchSegment(parts[0], strconv.Itoa(i)) {
+				continue
+			}
+
+			ok, err := setYAMLValue(node, i, parts, value, false)
+			if err != nil {
+				return false, err
+			}
+			matched = matched || ok
+		}
+	}
+
+	return matched, nil
+}
+
+func setYAMLValue(parent *yaml.Node, index int, parts []string, value interface{}, create bool) (bool, error) {
+	if len(parts) > 1 {
+		child := parent.Content[index]
+		if child.Kind == yaml.AliasNode {
+			child = child.Alias
+		}
+		if child.Kind != yaml.MappingNode && child.Kind != yaml.SequenceNode {
+			if create {
+				return false, fmt.Errorf("%q is not a mapping", parts[0])
+			}
+			return false, nil
+		}
+		return setYAML(child, parts[1:], value, create)
+	}
+
+	old := parent.Content[index]
+	replacement := &yaml.Node{}
+	if err := replacement.Encode(value); err != nil {
+		return false, err
+	}
+
+	replacement.HeadComment = old.HeadComment
+	replacement.LineComment = old.LineComment
+	replacement.FootComment = old.FootComment
+	if replacement.Kind == yaml.ScalarNode && old.Kind == yaml.ScalarNode && replacement.Tag == old.Tag {
+		replacement.Style = old.Style
+	}
+
+	parent.Content[index] = replacement
+	return true, nil
+}
+
+// jsonSpan is the position of a value in the source, objects also record
+// where their closing brace is and how members are separated.
+type jsonSpan struct {
+	start, end int
+	object     bool
+	empty      bool
+	sep        string
+}
+
+type jsonScanner struct {
+	data  []byte
+	pos   int
+	spans map[string]*jsonSpan
+	paths [][]string
+}
+
+func (s *jsonScanner) skip() {
+	for s.pos < len(s.data) && strings.IndexByte(" \t\r\n", s.data[s.pos]) >= 0 {
+		s.pos++
+	}
+}
+
+func (s *jsonScanner) fail(msg string) error {
+	return fmt.Errorf("invalid json at offset %d: %s", s.pos, msg)
+}
+
+func (s *jsonScanner) value(p []string) error {
+	s.skip()
+	if s.pos >= len(s.data) {
+		return s.fail("unexpected end of input")
+	}
+
+	span := &jsonSpan{start: s.pos}
+	key := strings.Join(p, "\x00")
+	s.spans[key] = span
+	s.paths = append(s.paths, p)
+
+	switch s.data[s.pos] {
+	case '{':
+		span.object = true
+		s.pos++
+		s.skip()
+		span.sep = string(s.data[span.start+1 : s.pos])
+		if s.pos < len(s.data) && s.data[s.pos] == '}' {
+			span.empty = true
+			s.pos++
+			break
+		}
+
+		for {
+			s.skip()
+			name, err := s.str()
+			if err != nil {
+				return err
+			}
+			s.skip()
+			if s.pos >= len(s.data) || s.data[s.pos] != ':' {
+				return s.fail("expected ':'")
+			}
+			s.pos++
+
+			if err = s.value(append(append([]string{}, p...), name)); err != nil {
+				return err
+			}
+			s.skip()
+			if s.pos >= len(s.data) {
+				return s.fail("unexpected end of input")
+			}
+			if s.data[s.pos] == '}' {
+				s.pos++
+				break
+			}
+			if s.data[s.pos] != ',' {
+				return s.fail("expected ',' or '}'")
+			}
+			s.pos++
+		}
+
+	case '[':
+		s.pos++
+		s.skip()
+		if s.pos < len(s.data) && s.data[s.pos] == ']' {
+			s.pos++
+			break
+		}
+
+		for i := 0; ; i++ {
+			if err := s.value(append(append([]string{}, p...), strconv.Itoa(i))); err != nil {
+				return err
+			}
+			s.skip()
+			if s.pos >= len(s.data) {
+				return s.fail("unexpected end of input")
+			}
+			if s.data[s.pos] == ']' {
+				s.pos++
+				break
+			}
+			if s.data[s.pos] != ',' {
+				return s.fail("expected ',' or ']'")
+			}
+			s.pos++
+		}
+
+	case '"':
+		if _, err := s.str(); err != nil {
+			return err
+		}
+
+	default:
+		for s.pos < len(s.data) && strings.IndexByte(",}] \t\r\n", s.data[s.pos]) < 0 {
+			s.pos++
+		}
+		if !json.Valid(s.data[span.start:s.pos]) {
+			return s.fail("invalid literal")
+		}
+	}
+
+	span.end = s.pos
+	return nil
+}
+
+func (s *jsonScanner) str() (string, error) {
+	if s.pos >= len(s.data) || s.data[s.pos] != '"' {
+		return "", s.fail("expected string")
+	}
+
+	start := s.pos
+	for s.pos++; s.pos < len(s.data); s.pos++ {
+		switch s.data[s.pos] {
+		case '\\':
+			s.pos++
+		case '"':
+			s.pos++
+			var out string
+			err := json.Unmarshal(s.data[start:s.pos], &out)
+			return out, err
+		}
+	}
+
+	return "", s.fail("unterminated string")
+}
+
+// configNode collects the keys that do not exist yet below an existing parent,
+// so keys sharing a missing ancestor end up in a single object or element.
+type configNode struct {
+	name     string
+	value    string
+	leaf     bool
+	children []*configNode
+}
+
+// add sets the value at the path below n, it fails when the path runs through
+// a value or ends at a node that already has children.
+func (n *configNode) add(parts []string, value string) bool {
+	for _, p := range parts {
+		if n.leaf {
+			return false
+		}
+
+		var next *configNode
+		for _, c := range n.children {
+			if c.name == p {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			next = &configNode{name: p}
+			n.children = append(n.children, next)
+		}
+		n = next
+	}
+
+	if n.leaf || len(n.children) > 0 {
+		return false
+	}
+	n.leaf, n.value = true, value
+	return true
+}
+
+func (n *configNode) json() string {
+	if n.leaf {
+		return n.value
+	}
+
+	members := make([]string, len(n.children))
+	for i, c := range n.children {
+		members[i] = c.jsonMember()
+	}
+	return "{" + strings.Join(members, ", ") + "}"
+}
+
+func (n *configNode) jsonMember() string {
+	name, _ := json.Marshal(n.name)
+	return string(name) + ": " + n.json()
+}
+
+func (n *configNode) xml() string {
+	text := n.value
+	for _, c := range n.children {
+		text += c.xml()
+	}
+	return "<" + n.name + ">" + text + "</" + n.name + ">"
+}
+
+type splice struct {
+	start, end int
+	text       string
+}
+
+func applySplices(data []byte, splices []splice) []byte {
+	sort.SliceStable(splices, func(i, j int) bool {
+		return splices[i].start > splices[j].start
+	})
+
+	out := append([]byte{}, data...)
+	for _, s := range splices {
+		out = append(out[:s.start], append([]byte(s.text), out[s.end:]...)...)
+	}
+
+	return out
+}
+
+func editJSON(data []byte, edits map[string]interface{}) ([]byte, error) {
+	s := &jsonScanner{data: data, spans: map[string]*jsonSpan{}}
+	if len(bytes.TrimSpace(data)) == 0 {
+		s.data = []byte("{}\n")
+	}
+	if err := s.value(nil); err != nil {
+		return nil, err
+	}
+
+	var splices []splice
+	// edits that already touch part of a value stop wildcards from replacing
+	// the value as a whole, and the other way around
+	var touched []splice
+	overlaps := func(start, end int) bool {
+		for _, t := range touched {
+			if start < t.end && t.start < end || start < t.start && t.start < end {
+				return true
+			}
+		}
+		return false
+	}
+	inserts := map[*jsonSpan]*configNode{}
+
+	for _, key := range sortedEdits(edits) {
+		parts := splitKeyPath(key)
+		value, err := json.Marshal(edits[key])
+		if err != nil {
+			return nil, err
+		}
+
+		matched := false
+		for _, p := range s.paths {
+			span := s.spans[strings.Join(p, "\x00")]
+			if !matchKeyPath(parts, p) || overlaps(span.start, span.end) {
+				continue
+			}
+
+			splices = append(splices, splice{start: span.start, end: span.end, text: string(value)})
+			touched = append(touched, splices[len(splices)-1])
+			matched = true
+		}
+		if matched || hasWildcard(parts) {
+			continue
+		}
+
+		// build whatever is missing below the deepest existing value, which
+		// has to be an object to hold the new member
+		depth := len(parts) - 1
+		for ; depth > 0; depth-- {
+			if span, ok := s.spans[strings.Join(parts[:depth], "\x00")]; ok {
+				if !span.object {
+					return nil, fmt.Errorf("config key %q could not be set, %q is not an object", key, strings.Join(parts[:depth], "."))
+				}
+				break
+			}
+		}
+
+		parent, ok := s.spans[strings.Join(parts[:depth], "\x00")]
+		if !ok || !parent.object {
+			return nil, fmt.Errorf("config key %q could not be set", key)
+		}
+
+		if inserts[parent] == nil {
+			inserts[parent] = &configNode{}
+		}
+		if !inserts[parent].add(parts[depth:], string(value)) {
+			return nil, fmt.Errorf("config key %q could not be set, it overlaps another new key", key)
+		}
+		touched = append(touched, splice{start: parent.end - 1, end: parent.end - 1})
+	}
+
+	for span, node := range inserts {
+		members := make([]string, len(node.children))
+		for i, c := range node.children {
+			members[i] = c.jsonMember()
+		}
+
+		sep := " "
+		if strings.Contains(span.sep, "\n") {
+			sep = span.sep
+		}
+
+		if span.empty {
+			text := strings.Join(members, ", ")
+			if strings.Contains(sep, "\n") {
+				text = sep + strings.Join(members, ","+sep) + "\n"
+			}
+			splices = append(splices, splice{start: span.start + 1, end: span.start + 1, text: text})
+			continue
+		}
+
+		// insert after the last member, before any whitespace ahead of the brace
+		at := span.end - 1
+		for at > span.start && strings.IndexByte(" \t\r\n", s.data[at-1]) >= 0 {
+			at--
+		}
+		splices = append(splices, splice{start: at, end: at, text: "," + sep + strings.Join(members, ","+sep)})
+	}
+
+	return applySplices(s.data, splices), nil
+}
+
+type xmlElement struct {
+	path         []string
+	tagStart     int
+	tagEnd       int
+	tag          string
+	children     bool
+	selfClosing  bool
+	contentStart int
+}
+
+func xmlAttr(name string) *regexp.Regexp {
+	return regexp.MustCompile(`(\s` + regexp.QuoteMeta(name) + `\s*=\s*)("[^"]*"|'[^']*')`)
+}
+
+func xmlEscape(v string) string {
+	buf := bytes.Buffer{}
+	xml.EscapeText(&buf, []byte(v))
+	return buf.String()
+}
+
+func editXML(data []byte, edits map[string]interface{}) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var (
+		stack    []*xmlElement
+		splices  []splice
+		elements = map[string]*xmlElement{}
+		ends     = map[*xmlElement]int{}
+		found    = map[string]bool{}
+	)
+
+	for {
+		before := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p := []string{t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = true
+				p = append(append([]string{}, parent.path...), t.Name.Local)
+			}
+
+			end := int(dec.InputOffset())
+			el := &xmlElement{path: p, tagStart: before, tagEnd: end, contentStart: end}
+			el.selfClosing = bytes.HasSuffix(bytes.TrimRight(data[before:end], " \t\r\n"), []byte("/>"))
+			stack = append(stack, el)
+			if _, ok := elements[strings.Join(p, "\x00")]; !ok {
+				elements[strings.Join(p, "\x00")] = el
+			}
+
+			// wildcards are applied first so exact keys overwrite what they set
+			el.tag = string(data[before:end])
+			keys := sortedEdits(edits)
+			for i := len(keys) - 1; i >= 0; i-- {
+				key := keys[i]
+				parts := splitKeyPath(key)
+				attr := parts[len(parts)-1]
+				if !strings.HasPrefix(attr, "@") || !matchKeyPath(parts[:len(parts)-1], p) {
+					continue
+				}
+
+				value := `"` + strings.ReplaceAll(xmlEscape(plainValue(edits[key])), `"`, "&quot;") + `"`
+				found[key] = true
+				if loc := xmlAttr(attr[1:]).FindStringSubmatchIndex(el.tag); loc != nil {
+					el.tag = el.tag[:loc[4]] + value + el.tag[loc[5]:]
+					continue
+				}
+
+				closing := strings.LastIndex(el.tag, ">")
+				if el.selfClosing {
+					closing = strings.LastIndex(el.tag, "/>")
+					for closing > 0 && el.tag[closing-1] == ' ' {
+						closing--
+					}
+				}
+				el.tag = el.tag[:closing] + " " + attr[1:] + "=" + value + el.tag[closing:]
+			}
+
+		case xml.EndElement:
+			el := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			ends[el] = before
+
+			for _, key := range sortedEdits(edits) {
+				parts := splitKeyPath(key)
+				if el.children || strings.HasPrefix(parts[len(parts)-1], "@") || !matchKeyPath(parts, el.path) {
+					continue
+				}
+
+				text := xmlEscape(plainValue(edits[key]))
+				if el.selfClosing {
+					tag := strings.TrimRight(strings.TrimSuffix(el.tag, "/>"), " ") + ">"
+					el.tag = tag + text + "</" + el.path[len(el.path)-1] + ">"
+				} else {
+					splices = append(splices, splice{start: el.contentStart, end: before, text: text})
+				}
+				found[key] = true
+				break
+			}
+
+			if el.tag != string(data[el.tagStart:el.tagEnd]) {
+				splices = append(splices, splice{start: el.tagStart, end: el.tagEnd, text: el.tag})
+			}
+		}
+	}
+
+	// missing elements are added as the last children of the deepest existing
+	// parent, attributes on missing elements are not supported
+	inserts := map[*xmlElement]*configNode{}
+	for _, key := range sortedEdits(edits) {
+		parts := splitKeyPath(key)
+		if found[key] || hasWildcard(parts) {
+			continue
+		}
+		if strings.HasPrefix(parts[len(parts)-1], "@") {
+			return nil, fmt.Errorf("config key %q could not be set, the element does not exist", key)
+		}
+
+		depth := len(parts) - 1
+		for ; depth > 0; depth-- {
+			if _, ok := elements[strings.Join(parts[:depth], "\x00")]; ok {
+				break
+			}
+		}
+
+		parent, ok := elements[strings.Join(parts[:depth], "\x00")]
+		if depth == 0 || !ok || parent.selfClosing {
+			return nil, fmt.Errorf("config key %q could not be set", key)
+		}
+
+		if inserts[parent] == nil {
+			inserts[parent] = &configNode{}
+		}
+		if !inserts[parent].add(parts[depth:], xmlEscape(plainValue(edits[key]))) {
+			return nil, fmt.Errorf("config key %q could not be set, it overlaps another new key", key)
+		}
+	}
+
+	for parent, node := range inserts {
+		// indent the new elements one level deeper than the closing tag when
+		// the closing tag is on its own line
+		at := ends[parent]
+		indent := at
+		for indent > 0 && (data[indent-1] == ' ' || data[indent-1] == '\t') {
+			indent--
+		}
+
+		text := ""
+		if indent > 0 && data[indent-1] == '\n' {
+			ws := string(data[indent:at])
+			step := "  "
+			if strings.Contains(ws, "\t") {
+				step = "\t"
+			}
+			for _, c := range node.children {
+				text += ws + step + c.xml() + "\n"
+			}
+			at = indent
+		} else {
+			for _, c := range node.children {
+				text += c.xml()
+			}
+		}
+		splices = append(splices, splice{start: at, end: at, text: text})
+	}
+
+	if len(stack) != 0 {
+		return nil, errors.New("invalid xml: unclosed elements")
+	}
+
+	return applySplices(data, splices), nil
+}
@@ -0,0 +1,401 @@
+package crocgodyl
+
+import (
+	"strings"
+	"testing"
+)
+
+type configEditTest struct {
+	name   string
+	input  string
+	edits  map[string]interface{}
+	output string
+	err    bool
+}
+
+func runConfigEditTests(t *testing.T, parser ConfigParser, tests []configEditTest) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := EditConfig([]byte(tt.input), parser, tt.edits)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", out)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != tt.output {
+				t.Fatalf("unexpected output\ngot:\n%s\nwant:\n%s", out, tt.output)
+			}
+
+			// applying the same edits again must not change anything
+			again, err := EditConfig(out, parser, tt.edits)
+			if err != nil {
+				t.Fatalf("unexpected error on second edit: %v", err)
+			}
+			if string(again) != string(out) {
+				t.Fatalf("second edit changed the output\ngot:\n%s\nwant:\n%s", again, out)
+			}
+		})
+	}
+}
+
+func TestEditJSON(t *testing.T) {
+	runConfigEditTests(t, ParserJSON, []configEditTest{
+		{
+			name:   "replace value",
+			input:  `{"a": 1, "b": "x"}`,
+			edits:  map[string]interface{}{"a": 2},
+			output: `{"a": 2, "b": "x"}`,
+		},
+		{
+			name:   "replace nested value",
+			input:  `{"a": {"b": 1}}`,
+			edits:  map[string]interface{}{"a.b": true},
+			output: `{"a": {"b": true}}`,
+		},
+		{
+			name:   "add key",
+			input:  `{"a": 1}`,
+			edits:  map[string]interface{}{"b": "x"},
+			output: `{"a": 1, "b": "x"}`,
+		},
+		{
+			name:   "add nested key",
+			input:  `{"a": {}}`,
+			edits:  map[string]interface{}{"a.b.c": 2},
+			output: `{"a": {"b": {"c": 2}}}`,
+		},
+		{
+			name:   "keep indentation",
+			input:  "{\n  \"a\": 1\n}\n",
+			edits:  map[string]interface{}{"b": 2},
+			output: "{\n  \"a\": 1,\n  \"b\": 2\n}\n",
+		},
+		{
+			name:   "wildcard",
+			input:  `{"a": {"x": 1, "y": 2}}`,
+			edits:  map[string]interface{}{"a.*": 0},
+			output: `{"a": {"x": 0, "y": 0}}`,
+		},
+		{
+			name:   "escaped dot",
+			input:  `{"a.b": 1}`,
+			edits:  map[string]interface{}{`a\.b`: 2},
+			output: `{"a.b": 2}`,
+		},
+		{
+			name:   "shared missing parent",
+			input:  `{"a": 1}`,
+			edits:  map[string]interface{}{"server.port": 1, "server.host": "x", "server.tls.on": true},
+			output: `{"a": 1, "server": {"host": "x", "port": 1, "tls": {"on": true}}}`,
+		},
+		{
+			name:  "new value and member below it",
+			input: `{"a": 1}`,
+			edits: map[string]interface{}{"b": 1, "b.c": 2},
+			err:   true,
+		},
+		{
+			name:  "scalar parent",
+			input: `{"a": {"b": 1}}`,
+			edits: map[string]interface{}{"a.b.c": 2},
+			err:   true,
+		},
+		{
+			name:  "array parent",
+			input: `{"a": [1]}`,
+			edits: map[string]interface{}{"a.b": 2},
+			err:   true,
+		},
+		{
+			name:  "invalid json",
+			input: `{"a": }`,
+			edits: map[string]interface{}{"a": 1},
+			err:   true,
+		},
+	})
+}
+
+func TestEditYAML(t *testing.T) {
+	runConfigEditTests(t, ParserYAML, []configEditTest{
+		{
+			name:   "replace value",
+			input:  "a: 1\nb: x\n",
+			edits:  map[string]interface{}{"a": 2},
+			output: "a: 2\nb: x\n",
+		},
+		{
+			name:   "keep comments",
+			input:  "# head\na: 1 # line\n",
+			edits:  map[string]interface{}{"a": 2},
+			output: "# head\na: 2 # line\n",
+		},
+		{
+			name:   "add nested key",
+			input:  "a:\n    b: 1\n",
+			edits:  map[string]interface{}{"a.c": "x"},
+			output: "a:\n    b: 1\n    c: x\n",
+		},
+		{
+			name:   "sequence index",
+			input:  "a:\n  - 1\n  - 2\n",
+			edits:  map[string]interface{}{"a.1": 3},
+			output: "a:\n  - 1\n  - 3\n",
+		},
+		{
+			name:   "wildcard",
+			input:  "a:\n  x: 1\n  y: 2\n",
+			edits:  map[string]interface{}{"a.*": 0},
+			output: "a:\n  x: 0\n  y: 0\n",
+		},
+		{
+			name:   "empty file",
+			input:  "",
+			edits:  map[string]interface{}{"a.b": 1},
+			output: "a:\n  b: 1\n",
+		},
+		{
+			name:  "scalar parent",
+			input: "a: 1\n",
+			edits: map[string]interface{}{"a.b": 2},
+			err:   true,
+		},
+	})
+}
+
+func TestEditXML(t *testing.T) {
+	runConfigEditTests(t, ParserXML, []configEditTest{
+		{
+			name:   "replace text",
+			input:  "<a><b>1</b></a>",
+			edits:  map[string]interface{}{"a.b": 2},
+			output: "<a><b>2</b></a>",
+		},
+		{
+			name:   "escape text",
+			input:  "<a><b>1</b></a>",
+			edits:  map[string]interface{}{"a.b": "x<y"},
+			output: "<a><b>x&lt;y</b></a>",
+		},
+		{
+			name:   "replace attribute",
+			input:  `<a><b x="1"/></a>`,
+			edits:  map[string]interface{}{"a.b.@x": 2},
+			output: `<a><b x="2"/></a>`,
+		},
+		{
+			name:   "add attribute",
+			input:  `<a><b>1</b></a>`,
+			edits:  map[string]interface{}{"a.b.@y": "z"},
+			output: `<a><b y="z">1</b></a>`,
+		},
+		{
+			name:   "add element",
+			input:  "<a>\n  <b>1</b>\n</a>\n",
+			edits:  map[string]interface{}{"a.c": 2},
+			output: "<a>\n  <b>1</b>\n  <c>2</c>\n</a>\n",
+		},
+		{
+			name:   "fill self closing element",
+			input:  "<a><b/></a>",
+			edits:  map[string]interface{}{"a.b": 1},
+			output: "<a><b>1</b></a>",
+		},
+		{
+			name:   "shared missing parent",
+			input:  "<root><a>1</a></root>",
+			edits:  map[string]interface{}{"root.b.c": 1, "root.b.d": 2},
+			output: "<root><a>1</a><b><c>1</c><d>2</d></b></root>",
+		},
+		{
+			name:   "shared missing parent indented",
+			input:  "<root>\n  <a>1</a>\n</root>\n",
+			edits:  map[string]interface{}{"root.b.c": 1, "root.b.d": 2, "root.e": 3},
+			output: "<root>\n  <a>1</a>\n  <b><c>1</c><d>2</d></b>\n  <e>3</e>\n</root>\n",
+		},
+		{
+			name:  "attribute on missing element",
+			input: "<a><b>1</b></a>",
+			edits: map[string]interface{}{"a.d.@y": 1},
+			err:   true,
+		},
+		{
+			name:  "missing root",
+			input: "<a/>",
+			edits: map[string]interface{}{"x.y": 1},
+			err:   true,
+		},
+	})
+}
+
+func TestEditINI(t *testing.T) {
+	runConfigEditTests(t, ParserINI, []configEditTest{
+		{
+			name:   "replace global value",
+			input:  "a = 1\n[s]\na = 2\n",
+			edits:  map[string]interface{}{"a": 3},
+			output: "a = 3\n[s]\na = 2\n",
+		},
+		{
+			name:   "replace section value",
+			input:  "a = 1\n[s]\na = 2\n",
+			edits:  map[string]interface{}{"s.a": 3},
+			output: "a = 1\n[s]\na = 3\n",
+		},
+		{
+			name:   "keep inline comment",
+			input:  "[s]\na = 1 ; note\n",
+			edits:  map[string]interface{}{"s.a": 2},
+			output: "[s]\na = 2 ; note\n",
+		},
+		{
+			name:   "add to section",
+			input:  "[s]\na = 1\n\n[t]\nb = 2\n",
+			edits:  map[string]interface{}{"s.c": 3},
+			output: "[s]\na = 1\nc = 3\n\n[t]\nb = 2\n",
+		},
+		{
+			name:   "add section",
+			input:  "a = 1\n",
+			edits:  map[string]interface{}{"s.b": 2},
+			output: "a = 1\n\n[s]\nb = 2\n",
+		},
+		{
+			name:   "crlf line endings",
+			input:  "[s]\r\na = 1\r\n",
+			edits:  map[string]interface{}{"s.a": 2},
+			output: "[s]\r\na = 2\r\n",
+		},
+	})
+}
+
+func TestEditProperties(t *testing.T) {
+	runConfigEditTests(t, ParserProperties, []configEditTest{
+		{
+			name:   "replace value",
+			input:  "# comment\nserver-port=25565\nmotd=hi\n",
+			edits:  map[string]interface{}{"server-port": 25566},
+			output: "# comment\nserver-port=25566\nmotd=hi\n",
+		},
+		{
+			name:   "dotted key",
+			input:  "a.b=1\n",
+			edits:  map[string]interface{}{"a.b": 2},
+			output: "a.b=2\n",
+		},
+		{
+			name:   "keep separator",
+			input:  "a: 1\n",
+			edits:  map[string]interface{}{"a": 2},
+			output: "a: 2\n",
+		},
+		{
+			name:   "replace continued value",
+			input:  "a=1\\\n  2\nb=3\n",
+			edits:  map[string]interface{}{"a": 4},
+			output: "a=4\nb=3\n",
+		},
+		{
+			name:   "add key",
+			input:  "a=1\n",
+			edits:  map[string]interface{}{"b": true},
+			output: "a=1\nb=true\n",
+		},
+		{
+			name:   "wildcard",
+			input:  "a1=x\na2=y\nb=z\n",
+			edits:  map[string]interface{}{"a*": "v"},
+			output: "a1=v\na2=v\nb=z\n",
+		},
+	})
+}
+
+func TestEditTOML(t *testing.T) {
+	runConfigEditTests(t, ParserTOML, []configEditTest{
+		{
+			name:   "replace value",
+			input:  "a = 1\n[t]\nb = \"x\" # note\n",
+			edits:  map[string]interface{}{"t.b": "y"},
+			output: "a = 1\n[t]\nb = \"y\" # note\n",
+		},
+		{
+			name:   "add key",
+			input:  "[t]\nb = 1\n",
+			edits:  map[string]interface{}{"t.c": 2},
+			output: "[t]\nb = 1\nc = 2\n",
+		},
+		{
+			name:   "multi-line array",
+			input:  "[x]\nk = [1,\n 2] # note\nj = 1\n",
+			edits:  map[string]interface{}{"x.k": 3},
+			output: "[x]\nk = 3 # note\nj = 1\n",
+		},
+		{
+			name:   "multi-line array with brackets in strings and comments",
+			input:  "k = [\n  \"]\", # ]\n  '['\n]\nj = 1\n",
+			edits:  map[string]interface{}{"j": 2},
+			output: "k = [\n  \"]\", # ]\n  '['\n]\nj = 2\n",
+		},
+		{
+			name:   "multi-line basic string",
+			input:  "a = \"\"\"\nb = 1\n\\\"\"\"\n\"\"\"\nb = 2\n",
+			edits:  map[string]interface{}{"a": "x", "b": 3},
+			output: "a = \"x\"\nb = 3\n",
+		},
+		{
+			name:   "multi-line literal string",
+			input:  "a = '''\nline\n''' # note\n",
+			edits:  map[string]interface{}{"a": "x"},
+			output: "a = \"x\" # note\n",
+		},
+		{
+			name:   "inline table over several lines",
+			input:  "a = { b = 1,\n  c = 2 }\nd = 4\n",
+			edits:  map[string]interface{}{"a": 1},
+			output: "a = 1\nd = 4\n",
+		},
+		{
+			name:   "add key after multi-line value",
+			input:  "[t]\nk = [\n  1,\n]\n",
+			edits:  map[string]interface{}{"t.c": 2},
+			output: "[t]\nk = [\n  1,\n]\nc = 2\n",
+		},
+		{
+			name:  "unterminated array",
+			input: "k = [1,\n 2\n",
+			edits: map[string]interface{}{"k": 3},
+			err:   true,
+		},
+	})
+}
+
+func TestConfigParserFor(t *testing.T) {
+	for name, want := range map[string]ConfigParser{
+		"server.properties": ParserProperties,
+		"config.YML":        ParserYAML,
+		"bukkit.yaml":       ParserYAML,
+		"a/b/c.json":        ParserJSON,
+		"game.ini":          ParserINI,
+		"Config.xml":        ParserXML,
+	} {
+		if got, ok := ConfigParserFor(name); !ok || got != want {
+			t.Errorf("ConfigParserFor(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, ok := ConfigParserFor("run.sh"); ok {
+		t.Error("expected no parser for a shell script")
+	}
+}
+
+func TestSplitKeyPath(t *testing.T) {
+	got := strings.Join(splitKeyPath(`a.b\.c.d`), "|")
+	if got != "a|b.c|d" {
+		t.Fatalf("unexpected parts %q", got)
+	}
+}
@@ -2,7 +2,6 @@ package crocgodyl
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,30 +9,50 @@ import (
 
 const Version = "1.0.0"
 
+const defaultUserAgent = "Crocgodyl v" + Version
+
 type Application struct {
 	PanelURL string
 	ApiKey   string
 	Http     *http.Client
+
+	userAgent string
+	panel     *panelCache
 }
 
 type Client struct {
 	PanelURL string
 	ApiKey   string
 	Http     *http.Client
+
+	userAgent string
+	panel     *panelCache
 }
 
-func NewApp(url, key string) (*Application, error) {
-	if url == "" {
-		return nil, errors.New("a valid panel url is required")
+func NewApp(url, key string, opts ...Option) (*Application, error) {
+	url, err := normalizeURL(url)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkKey(key, true); err != nil {
+		return nil, err
+	}
+
+	o, err := buildOptions(opts)
+	if err != nil {
+		return nil, err
 	}
-	if key == "" {
-		return nil, errors.New("a valid application api key is required")
+	client, err := o.httpClient()
+	if err != nil {
+		return nil, err
 	}
 
 	app := &Application{
-		PanelURL: url,
-		ApiKey:   key,
-		Http:     &http.Client{},
+		PanelURL:  url,
+		ApiKey:    key,
+		Http:      client,
+		userAgent: o.userAgentHeader(),
+		panel:     newPanelCache(o.flavor),
 	}
 
 	return app, nil
@@ -42,7 +61,7 @@ func NewApp(url, key string) (*Application, error) {
 func (a *Application) newRequest(method, path string, body io.Reader) *http.Request {
 	req, _ := http.NewRequest(method, fmt.Sprintf("%s/api/application%s", a.PanelURL, path), body)
 
-	req.Header.Set("User-Agent", "Crocgodyl v"+Version)
+	req.Header.Set("User-Agent", agent(a.userAgent))
 	req.Header.Set("Authorization", "Bearer "+a.ApiKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
@@ -50,18 +69,30 @@ func (a *Application) newRequest(method, path string, body io.Reader) *http.Requ
 	return req
 }
 
-func NewClient(url, key string) (*Client, error) {
-	if url == "" {
-		return nil, errors.New("a valid panel url is required")
+func NewClient(url, key string, opts ...Option) (*Client, error) {
+	url, err := normalizeURL(url)
+	if err != nil {
+		return nil, err
 	}
-	if key == "" {
-		return nil, errors.New("a valid client api key is required")
+	if err = checkKey(key, false); err != nil {
+		return nil, err
+	}
+
+	o, err := buildOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	hc, err := o.httpClient()
+	if err != nil {
+		return nil, err
 	}
 
 	client := &Client{
-		PanelURL: url,
-		ApiKey:   key,
-		Http:     &http.Client{},
+		PanelURL:  url,
+		ApiKey:    key,
+		Http:      hc,
+		userAgent: o.userAgentHeader(),
+		panel:     newPanelCache(o.flavor),
 	}
 
 	return client, nil
@@ -70,7 +101,7 @@ func NewClient(url, key string) (*Client, error) {
 func (a *Client) newRequest(method, path string, body io.Reader) *http.Request {
 	req, _ := http.NewRequest(method, fmt.Sprintf("%s/api/client%s", a.PanelURL, path), body)
 
-	req.Header.Set("User-Agent", "Crocgodyl v"+Version)
+	req.Header.Set("User-Agent", agent(a.userAgent))
 	req.Header.Set("Authorization", "Bearer "+a.ApiKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
@@ -78,6 +109,15 @@ func (a *Client) newRequest(method, path string, body io.Reader) *http.Request {
 	return req
 }
 
+// agent falls back to the default user agent for clients that were not created
+// with NewApp or NewClient.
+func agent(userAgent string) string {
+	if userAgent == "" {
+		return defaultUserAgent
+	}
+	return userAgent
+}
+
 func validate(res *http.Response) ([]byte, error) {
 	switch res.StatusCode {
 	case http.StatusOK:
@@ -1,6 +1,10 @@
 package crocgodyl
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
 
 type Error struct {
 	Code   string      `json:"code"`
@@ -20,3 +24,40 @@ type ApiError struct {
 func (e *ApiError) Error() string {
 	return fmt.Sprintf("%d unexpected error(s)", len(e.Errors))
 }
+
+// FileNotFoundError is returned when a server file, or the directory it should
+// be in, does not exist. It matches fs.ErrNotExist with errors.Is.
+type FileNotFoundError struct {
+	Path string
+}
+
+func (e *FileNotFoundError) Error() string {
+	return fmt.Sprintf("file %s does not exist", e.Path)
+}
+
+func (e *FileNotFoundError) Is(target error) bool {
+	return target == fs.ErrNotExist
+}
+
+// IsNotFound reports whether the error is a FileNotFoundError or otherwise
+// matches fs.ErrNotExist. Other not found responses, such as for an unknown
+// server, are not files that are missing and do not match.
+func IsNotFound(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// isMissingFile reports whether the panel passed on a not found response of
+// the daemon, which is how file endpoints report a missing file or directory.
+// Unknown servers and routes are a not found error of the panel itself.
+func isMissingFile(err error) bool {
+	var errs *ApiError
+	if errors.As(err, &errs) {
+		for _, e := range errs.Errors {
+			if e.Status == "404" && e.Code == "DaemonConnectionException" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
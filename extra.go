@@ -0,0 +1,323 @@
+package crocgodyl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Extra holds the attributes of a response the model has no field for, such
+// as fields added by Pelican or a forked panel. Attributes of nested objects
+// use their dotted path, e.g. "limits.oom_killer", and dots or backslashes in
+// an attribute name are escaped with a backslash, e.g. `foo\.bar`. Values that
+// do not match the type of their field are kept here as well and leave the
+// field empty, so a changed field does not fail the whole call. They are kept
+// when the model is encoded again, unless the field has been set since.
+type Extra map[string]json.RawMessage
+
+func (e Extra) Has(name string) bool {
+	_, ok := e[name]
+	return ok
+}
+
+// Decode unmarshals the named attribute into v.
+func (e Extra) Decode(name string, v interface{}) error {
+	raw, ok := e[name]
+	if !ok {
+		return fmt.Errorf("attribute %s does not exist", name)
+	}
+	return json.Unmarshal(raw, v)
+}
+
+var extraKeyEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
+// splitExtraKey splits a key of Extra at its first unescaped dot, returning
+// the unescaped attribute name and the escaped path below it.
+func splitExtraKey(key string) (name, rest string, nested bool) {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key):
+			i++
+			b.WriteByte(key[i])
+		case key[i] == '.':
+			return b.String(), key[i+1:], true
+		default:
+			b.WriteByte(key[i])
+		}
+	}
+	return b.String(), "", false
+}
+
+type structField struct {
+	name  string
+	index int
+}
+
+var (
+	structFieldsCache sync.Map
+	unmarshalerType   = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// structFields returns the json names of the struct's exported fields.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		fields = append(fields, structField{name: name, index: i})
+	}
+
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+// matchField finds the field for a key the way the json package does, an
+// exact match is preferred over a case insensitive one.
+func matchField(fields []structField, key string) (int, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f.index, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f.index, true
+		}
+	}
+	return 0, false
+}
+
+// isPlainStruct reports whether the fields of a struct are decoded one by one,
+// types with their own decoding such as time.Time are decoded as a whole.
+func isPlainStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+// decodeStruct decodes an object into the struct v field by field, collecting
+// unknown and mismatched attributes in extra.
+func decodeStruct(buf []byte, v reflect.Value, prefix string, extra Extra) error {
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &attrs); err != nil {
+		return err
+	}
+
+	fields := structFields(v.Type())
+	for key, raw := range attrs {
+		path := prefix + extraKeyEscaper.Replace(key)
+		i, ok := matchField(fields, key)
+		if !ok {
+			extra[path] = raw
+			continue
+		}
+
+		f := v.Field(i)
+		if isPlainStruct(f.Type()) && !bytes.Equal(raw, []byte("null")) {
+			if err := decodeStruct(raw, f, path+".", extra); err != nil {
+				f.Set(reflect.Zero(f.Type()))
+				extra[path] = raw
+			}
+			continue
+		}
+
+		if err := json.Unmarshal(raw, f.Addr().Interface()); err != nil {
+			f.Set(reflect.Zero(f.Type()))
+			extra[path] = raw
+		}
+	}
+
+	return nil
+}
+
+// unmarshalExtra decodes buf into v, a pointer to a struct without its own
+// UnmarshalJSON method, and returns the attributes that did not fit.
+func unmarshalExtra(buf []byte, v interface{}) (Extra, error) {
+	extra := Extra{}
+	if err := decodeStruct(buf, reflect.ValueOf(v).Elem(), "", extra); err != nil {
+		return nil, err
+	}
+
+	if len(extra) == 0 {
+		return nil, nil
+	}
+	return extra, nil
+}
+
+// marshalExtra encodes v with the extra attributes, which replace fields of
+// the same name that are still empty and are otherwise added after them in
+// alphabetical order.
+func marshalExtra(v interface{}, extra Extra) ([]byte, error) {
+	buf, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return buf, err
+	}
+
+	return mergeExtra(buf, unsetExtra(reflect.ValueOf(v), extra))
+}
+
+// unsetExtra leaves out the values kept for fields that were set after the
+// model was decoded.
+func unsetExtra(v reflect.Value, extra Extra) Extra {
+	kept := Extra{}
+	for k, raw := range extra {
+		if f, ok := fieldByPath(v, k); ok && !f.IsZero() {
+			continue
+		}
+		kept[k] = raw
+	}
+	return kept
+}
+
+// fieldByPath returns the field of the struct v a key of Extra belongs to, ok
+// is false for attributes without a field.
+func fieldByPath(v reflect.Value, key string) (f reflect.Value, ok bool) {
+	for {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		name, rest, nested := splitExtraKey(key)
+		i, ok := matchField(structFields(v.Type()), name)
+		if !ok {
+			return reflect.Value{}, false
+		}
+
+		v = v.Field(i)
+		if !nested {
+			return v, true
+		}
+		key = rest
+	}
+}
+
+type objectMember struct {
+	key string
+	raw json.RawMessage
+}
+
+func objectMembers(buf []byte) ([]objectMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('{') {
+		return nil, errors.New("expected a json object")
+	}
+
+	var members []objectMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		members = append(members, objectMember{key: tok.(string), raw: raw})
+	}
+
+	return members, nil
+}
+
+func mergeExtra(buf []byte, extra Extra) ([]byte, error) {
+	direct := map[string]json.RawMessage{}
+	nested := map[string]Extra{}
+	for k, raw := range extra {
+		name, rest, ok := splitExtraKey(k)
+		if !ok {
+			direct[name] = raw
+			continue
+		}
+		if nested[name] == nil {
+			nested[name] = Extra{}
+		}
+		nested[name][rest] = raw
+	}
+
+	// objects that are null are replaced when extra attributes belong in them
+	if bytes.Equal(buf, []byte("null")) {
+		buf = []byte("{}")
+	}
+	members, err := objectMembers(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteByte('{')
+	write := func(key string, raw []byte) {
+		if out.Len() > 1 {
+			out.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		out.Write(name)
+		out.WriteByte(':')
+		if len(raw) == 0 {
+			raw = []byte("null")
+		}
+		out.Write(raw)
+	}
+
+	for _, m := range members {
+		raw := []byte(m.raw)
+		if d, ok := direct[m.key]; ok {
+			raw = d
+			delete(direct, m.key)
+		} else if n, ok := nested[m.key]; ok {
+			if raw, err = mergeExtra(raw, n); err != nil {
+				return nil, err
+			}
+			delete(nested, m.key)
+		}
+		write(m.key, raw)
+	}
+
+	keys := make([]string, 0, len(direct)+len(nested))
+	for k := range direct {
+		keys = append(keys, k)
+	}
+	for k := range nested {
+		if _, ok := direct[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if raw, ok := direct[k]; ok {
+			write(k, raw)
+			continue
+		}
+
+		raw, err := mergeExtra([]byte("{}"), nested[k])
+		if err != nil {
+			return nil, err
+		}
+		write(k, raw)
+	}
+
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
@@ -1,3 +1,8 @@
 module github.com/parkervcp/crocgodyl
 
 go 1.17
+
+require (
+	github.com/gorilla/websocket v1.5.0
+	gopkg.in/yaml.v3 v3.0.1
+)
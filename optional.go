@@ -0,0 +1,27 @@
+package crocgodyl
+
+// The fields of update descriptors are pointers so that a field can be left
+// out, set to its zero value or set to a value. These helpers return a pointer
+// to a value for setting them, such as:
+//
+//	app.UpdateUser(1, crocgodyl.UpdateUserDescriptor{RootAdmin: crocgodyl.Bool(false)})
+
+func Bool(v bool) *bool {
+	return &v
+}
+
+func String(v string) *string {
+	return &v
+}
+
+func Int(v int) *int {
+	return &v
+}
+
+func Int32(v int32) *int32 {
+	return &v
+}
+
+func Int64(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,336 @@
+package crocgodyl
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Option configures the http client and checks used by NewApp and NewClient.
+type Option func(*options) error
+
+type options struct {
+	http            *http.Client
+	tls             *tls.Config
+	proxy           *url.URL
+	dialTimeout     time.Duration
+	responseTimeout time.Duration
+	timeout         time.Duration
+	userAgent       string
+	flavor          Flavor
+	transport       bool
+}
+
+// WithHTTPClient uses a copy of the given client, other options are applied
+// on top of it and require its transport to be nil or an *http.Transport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) error {
+		if client == nil {
+			return errors.New("http client can not be nil")
+		}
+		o.http = client
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration the other TLS options add to.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) error {
+		if config == nil {
+			return errors.New("tls config can not be nil")
+		}
+		o.tls = config.Clone()
+		o.transport = true
+		return nil
+	}
+}
+
+// WithCABundle trusts the PEM encoded certificates in addition to the system
+// roots, for panels using a private certificate authority.
+func WithCABundle(pem []byte) Option {
+	return func(o *options) error {
+		t := o.tlsConfig()
+		if t.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			t.RootCAs = pool
+		}
+
+		if !t.RootCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no certificates found in the ca bundle")
+		}
+		return nil
+	}
+}
+
+// WithCAFile is WithCABundle reading the certificates from a file.
+func WithCAFile(path string) Option {
+	return func(o *options) error {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err = WithCABundle(pem)(o); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// WithClientCertificate presents the certificate to panels behind a proxy
+// requiring mutual TLS.
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(o *options) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+
+		t := o.tlsConfig()
+		t.Certificates = append(t.Certificates, cert)
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables certificate verification, only use this for
+// testing against panels with self-signed certificates.
+func WithInsecureSkipVerify() Option {
+	return func(o *options) error {
+		o.tlsConfig().InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// WithProxy sends requests through the proxy, the http, https and socks5
+// schemes are supported. Without this option the environment is used.
+func WithProxy(proxy string) Option {
+	return func(o *options) error {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+		}
+		if u.Host == "" {
+			return errors.New("invalid proxy url: missing host")
+		}
+
+		o.proxy = u
+		o.transport = true
+		return nil
+	}
+}
+
+// WithDialTimeout limits how long connecting to the panel may take.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.dialTimeout = d
+		o.transport = true
+		return nil
+	}
+}
+
+// WithResponseTimeout limits how long to wait for response headers once the
+// request was sent, unlike WithTimeout it does not limit reading the body.
+func WithResponseTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.responseTimeout = d
+		o.transport = true
+		return nil
+	}
+}
+
+// WithTimeout limits the duration of every request including reading the
+// response body, which includes file downloads and uploads.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) error {
+		o.timeout = d
+		return nil
+	}
+}
+
+// WithUserAgent appends a suffix such as "mytool/1.2" to the user agent.
+func WithUserAgent(suffix string) Option {
+	return func(o *options) error {
+		o.userAgent = strings.TrimSpace(suffix)
+		return nil
+	}
+}
+
+// WithFlavor declares the panel software, capability checks then use the
+// features of its current release instead of probing the panel.
+func WithFlavor(flavor Flavor) Option {
+	return func(o *options) error {
+		if _, ok := flavorCapabilities[flavor]; !ok {
+			return fmt.Errorf("the capabilities of %q panels can not be declared, they have to be probed", flavor)
+		}
+		o.flavor = flavor
+		return nil
+	}
+}
+
+func (o *options) tlsConfig() *tls.Config {
+	if o.tls == nil {
+		o.tls = &tls.Config{}
+	}
+	o.transport = true
+	return o.tls
+}
+
+func buildOptions(opts []Option) (*options, error) {
+	o := &options{}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+func (o *options) userAgentHeader() string {
+	if o.userAgent == "" {
+		return defaultUserAgent
+	}
+	return defaultUserAgent + " " + o.userAgent
+}
+
+func (o *options) httpClient() (*http.Client, error) {
+	client := &http.Client{}
+	if o.http != nil {
+		*client = *o.http
+	}
+	if o.timeout > 0 {
+		client.Timeout = o.timeout
+	}
+	if !o.transport {
+		return client, nil
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("can not apply options to a %T transport", t)
+	}
+
+	if o.tls != nil {
+		transport.TLSClientConfig = o.tls
+	}
+	if o.proxy != nil {
+		transport.Proxy = http.ProxyURL(o.proxy)
+	}
+	if o.dialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   o.dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if o.responseTimeout > 0 {
+		transport.ResponseHeaderTimeout = o.responseTimeout
+	}
+
+	client.Transport = transport
+	return client, nil
+}
+
+// normalizeURL checks the panel url and removes trailing slashes, so it can be
+// joined with the api paths.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("a valid panel url is required")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid panel url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid panel url %q: the scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid panel url %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid panel url %q: query strings are not supported", raw)
+	}
+
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+var (
+	applicationKeyPrefixes = []string{"ptla_", "peli_"}
+	clientKeyPrefixes      = []string{"ptlc_", "plcn_"}
+)
+
+func hasPrefix(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKey rejects keys meant for the other api. Keys without a known prefix
+// are allowed since older panels issued keys without one.
+func checkKey(key string, app bool) error {
+	if key == "" {
+		if app {
+			return errors.New("a valid application api key is required")
+		}
+		return errors.New("a valid client api key is required")
+	}
+
+	if app && hasPrefix(key, clientKeyPrefixes) {
+		return errors.New("the key is a client api key, NewApp requires an application api key")
+	}
+	if !app && hasPrefix(key, applicationKeyPrefixes) {
+		return errors.New("the key is an application api key, NewClient requires a client api key")
+	}
+
+	return nil
+}
+
+// websocketDialer uses the proxy and TLS settings of the client's transport
+// for console connections.
+func (c *Client) websocketDialer() *websocket.Dialer {
+	transport := c.Http.Transport
+	if capture, ok := transport.(*captureTransport); ok {
+		transport = capture.base
+	}
+
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return websocket.DefaultDialer
+	}
+
+	d := *websocket.DefaultDialer
+	d.Proxy = t.Proxy
+	d.TLSClientConfig = t.TLSClientConfig
+	if t.DialContext != nil {
+		d.NetDialContext = t.DialContext
+	}
+	return &d
+}
@@ -0,0 +1,546 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Flavor is the panel software behind the api.
+type Flavor string
+
+const (
+	FlavorUnknown     Flavor = ""
+	FlavorPterodactyl Flavor = "pterodactyl"
+	FlavorPelican     Flavor = "pelican"
+	// FlavorFork is a panel based on Pterodactyl whose endpoints or fields
+	// differ from the upstream releases.
+	FlavorFork Flavor = "fork"
+)
+
+// Capability is an optional api feature that not every panel provides.
+type Capability string
+
+const (
+	CapabilityActivityLogs  Capability = "activity_logs"
+	CapabilitySSHKeys       Capability = "ssh_keys"
+	CapabilityLocations     Capability = "locations"
+	CapabilityNests         Capability = "nests"
+	CapabilityEggs          Capability = "eggs"
+	CapabilityMounts        Capability = "mounts"
+	CapabilityDatabaseHosts Capability = "database_hosts"
+	CapabilityRoles         Capability = "roles"
+)
+
+// Capabilities is the set of features a probe found.
+type Capabilities map[Capability]bool
+
+func (c Capabilities) Has(capability Capability) bool {
+	return c[capability]
+}
+
+// List returns the capabilities in alphabetical order.
+func (c Capabilities) List() []Capability {
+	list := make([]Capability, 0, len(c))
+	for capability, ok := range c {
+		if ok {
+			list = append(list, capability)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	return list
+}
+
+// PanelInfo describes a panel as seen by a probe. Version is taken from a
+// header when the panel or a proxy in front of it reports one. Otherwise it
+// is the range of Pterodactyl releases with the client endpoints the probe
+// found, so only a client probe narrows it down.
+type PanelInfo struct {
+	Flavor       Flavor        `json:"flavor"`
+	Version      VersionRange  `json:"version"`
+	Latency      time.Duration `json:"latency"`
+	Capabilities Capabilities  `json:"capabilities"`
+}
+
+// VersionRange is a range of panel releases, Min is inclusive and Max is
+// exclusive. A bound is empty when nothing limits it. Exact is set instead
+// when the version was reported.
+type VersionRange struct {
+	Exact string `json:"exact,omitempty"`
+	Min   string `json:"min,omitempty"`
+	Max   string `json:"max,omitempty"`
+}
+
+// String formats the range as ">=1.8.0 <1.11.0", or returns the exact version
+// or an empty string for an unknown range.
+func (v VersionRange) String() string {
+	if v.Exact != "" {
+		return v.Exact
+	}
+
+	var parts []string
+	if v.Min != "" {
+		parts = append(parts, ">="+v.Min)
+	}
+	if v.Max != "" {
+		parts = append(parts, "<"+v.Max)
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	ErrUnreachable      = errors.New("the panel is unreachable")
+	ErrNotPanel         = errors.New("the url does not point to a panel api")
+	ErrInvalidKey       = errors.New("the api key is invalid or expired")
+	ErrPermissionDenied = errors.New("the api key is not permitted to access the resource")
+)
+
+// ProbeError is returned when a probe fails, Kind is one of ErrUnreachable,
+// ErrNotPanel, ErrInvalidKey or ErrPermissionDenied and matches with
+// errors.Is.
+type ProbeError struct {
+	Kind   error
+	Status int
+	Err    error
+}
+
+func (e *ProbeError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProbeError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// UnsupportedError is returned by calls that need a capability the panel does
+// not have.
+type UnsupportedError struct {
+	Capability Capability
+	Flavor     Flavor
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.Flavor == FlavorUnknown {
+		return fmt.Sprintf("the panel does not support %s", e.Capability)
+	}
+	return fmt.Sprintf("the %s panel does not support %s", e.Flavor, e.Capability)
+}
+
+// flavorCapabilities are the capabilities of the current release of each
+// flavor, used with WithFlavor.
+var flavorCapabilities = map[Flavor][]Capability{
+	FlavorPterodactyl: {CapabilityActivityLogs, CapabilitySSHKeys, CapabilityLocations, CapabilityNests},
+	FlavorPelican: {CapabilityActivityLogs, CapabilitySSHKeys, CapabilityEggs, CapabilityMounts,
+		CapabilityDatabaseHosts, CapabilityRoles},
+}
+
+// panelCache keeps the result of the first probe for capability checks.
+type panelCache struct {
+	mu   sync.Mutex
+	info *PanelInfo
+}
+
+func newPanelCache(flavor Flavor) *panelCache {
+	caps, ok := flavorCapabilities[flavor]
+	if !ok {
+		return &panelCache{}
+	}
+
+	info := &PanelInfo{Flavor: flavor, Capabilities: Capabilities{}}
+	for _, capability := range caps {
+		info.Capabilities[capability] = true
+	}
+	return &panelCache{info: info}
+}
+
+func (p *panelCache) get(probe func() (*PanelInfo, error)) (*PanelInfo, error) {
+	if p == nil {
+		return probe()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.info != nil {
+		return p.info, nil
+	}
+
+	info, err := probe()
+	if err != nil {
+		return nil, err
+	}
+	p.info = info
+	return info, nil
+}
+
+func (p *panelCache) set(info *PanelInfo) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.info = info
+	p.mu.Unlock()
+}
+
+// versionHeaders are checked in order for a reported panel version, the
+// flavor is only taken from a header naming it.
+var versionHeaders = []struct {
+	name   string
+	flavor Flavor
+}{
+	{"X-Pelican-Version", FlavorPelican},
+	{"X-Pterodactyl-Version", FlavorPterodactyl},
+	{"X-Panel-Version", FlavorUnknown},
+}
+
+func detectFromHeaders(info *PanelInfo, header http.Header) {
+	for _, h := range versionHeaders {
+		if v := header.Get(h.name); v != "" {
+			info.Version = VersionRange{Exact: strings.TrimPrefix(v, "v")}
+			info.Flavor = h.flavor
+			return
+		}
+	}
+}
+
+// pterodactylReleases are the Pterodactyl releases that added a capability,
+// oldest first.
+var pterodactylReleases = []struct {
+	capability Capability
+	version    string
+}{
+	{CapabilitySSHKeys, "1.8.0"},
+	{CapabilityActivityLogs, "1.11.0"},
+}
+
+// pterodactylVersion narrows down the release of a Pterodactyl panel from the
+// capabilities that were probed, capabilities that were not checked at all
+// are ignored. A reported version is kept as it is.
+func pterodactylVersion(current VersionRange, caps Capabilities) VersionRange {
+	if current.Exact != "" {
+		return current
+	}
+
+	var v VersionRange
+	newest := -1
+	for i, r := range pterodactylReleases {
+		if caps.Has(r.capability) {
+			newest = i
+			v.Min = r.version
+		}
+	}
+
+	for _, r := range pterodactylReleases[newest+1:] {
+		if probed, ok := caps[r.capability]; ok && !probed {
+			v.Max = r.version
+			break
+		}
+	}
+
+	return v
+}
+
+// probeResponse classifies the response of the probe request and returns its
+// body.
+func probeResponse(res *http.Response) ([]byte, error) {
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		res.Body.Close()
+		return nil, &ProbeError{Kind: ErrInvalidKey, Status: res.StatusCode}
+	case http.StatusForbidden:
+		_, err := validate(res)
+		return nil, &ProbeError{Kind: ErrPermissionDenied, Status: res.StatusCode, Err: err}
+	case http.StatusNotFound:
+		res.Body.Close()
+		return nil, &ProbeError{Kind: ErrNotPanel, Status: res.StatusCode}
+	}
+
+	// a wrong url usually ends up at the login page of the panel
+	if !strings.Contains(res.Header.Get("Content-Type"), "json") {
+		res.Body.Close()
+		return nil, &ProbeError{Kind: ErrNotPanel, Status: res.StatusCode,
+			Err: fmt.Errorf("unexpected content type %q", res.Header.Get("Content-Type"))}
+	}
+
+	return validate(res)
+}
+
+// endpointExists reports whether the panel has a route for the path, missing
+// permissions still mean the endpoint exists.
+func endpointExists(client *http.Client, req *http.Request) (bool, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return false, &ProbeError{Kind: ErrUnreachable, Err: err}
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return false, nil
+	}
+	return res.StatusCode < 500, nil
+}
+
+// attributeKeys returns the attribute names of the first item of a list or of
+// a single object response.
+func attributeKeys(buf []byte) map[string]bool {
+	var model struct {
+		Data []struct {
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+		Attributes map[string]json.RawMessage `json:"attributes"`
+	}
+	if err := json.Unmarshal(buf, &model); err != nil {
+		return nil
+	}
+
+	attrs := model.Attributes
+	if len(model.Data) > 0 {
+		attrs = model.Data[0].Attributes
+	}
+	if attrs == nil {
+		return nil
+	}
+
+	keys := make(map[string]bool, len(attrs))
+	for k := range attrs {
+		keys[k] = true
+	}
+	return keys
+}
+
+func hasUnknownKeys(keys map[string]bool, known []string) bool {
+	if keys == nil {
+		return false
+	}
+
+	set := make(map[string]bool, len(known))
+	for _, k := range known {
+		set[k] = true
+	}
+	for k := range keys {
+		if !set[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Application) newProbeRequest(path string) *http.Request {
+	return a.newRequest("GET", path+"?per_page=1", nil)
+}
+
+var (
+	applicationEndpoints = []struct {
+		capability Capability
+		path       string
+	}{
+		{CapabilityLocations, "/locations"},
+		{CapabilityNests, "/nests"},
+		{CapabilityEggs, "/eggs"},
+		{CapabilityMounts, "/mounts"},
+		{CapabilityDatabaseHosts, "/database-hosts"},
+		{CapabilityRoles, "/roles"},
+	}
+
+	pterodactylUserKeys = []string{"id", "external_id", "uuid", "username", "email", "first_name",
+		"last_name", "language", "root_admin", "2fa", "created_at", "updated_at"}
+)
+
+// Probe checks that the panel is reachable and the key is valid with a single
+// user lookup, then detects the flavor and capabilities with a request per
+// optional endpoint. Failures of the first request are a *ProbeError. The
+// result is kept for RequireCapability. The application API has no endpoints
+// that tell releases apart, so the version is only set from a header.
+func (a *Application) Probe() (*PanelInfo, error) {
+	info, err := a.probe()
+	if err != nil {
+		return nil, err
+	}
+
+	a.panel.set(info)
+	return info, nil
+}
+
+func (a *Application) probe() (*PanelInfo, error) {
+	start := time.Now()
+	res, err := a.Http.Do(a.newProbeRequest("/users"))
+	if err != nil {
+		return nil, &ProbeError{Kind: ErrUnreachable, Err: err}
+	}
+
+	info := &PanelInfo{Latency: time.Since(start), Capabilities: Capabilities{}}
+	detectFromHeaders(info, res.Header)
+
+	buf, err := probeResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range applicationEndpoints {
+		ok, err := endpointExists(a.Http, a.newProbeRequest(e.path))
+		if err != nil {
+			return nil, err
+		}
+		info.Capabilities[e.capability] = ok
+	}
+
+	if info.Flavor == FlavorUnknown {
+		caps := info.Capabilities
+		switch {
+		case hasPrefix(a.ApiKey, []string{"peli_"}) || caps.Has(CapabilityRoles):
+			info.Flavor = FlavorPelican
+		case caps.Has(CapabilityLocations) && caps.Has(CapabilityNests) &&
+			!hasUnknownKeys(attributeKeys(buf), pterodactylUserKeys):
+			info.Flavor = FlavorPterodactyl
+		default:
+			info.Flavor = FlavorFork
+		}
+	}
+
+	return info, nil
+}
+
+// Panel returns the result of the last probe or the flavor declared with
+// WithFlavor, or nil if there was neither.
+func (a *Application) Panel() *PanelInfo {
+	if a.panel == nil {
+		return nil
+	}
+
+	a.panel.mu.Lock()
+	defer a.panel.mu.Unlock()
+	return a.panel.info
+}
+
+// RequireCapability returns an *UnsupportedError if the panel lacks the
+// capability, the panel is probed on first use.
+func (a *Application) RequireCapability(capability Capability) error {
+	info, err := a.panel.get(a.probe)
+	if err != nil {
+		return err
+	}
+	if !info.Capabilities.Has(capability) {
+		return &UnsupportedError{Capability: capability, Flavor: info.Flavor}
+	}
+	return nil
+}
+
+// knownUnsupported is RequireCapability without probing, for calls that
+// predate capability checks.
+func (a *Application) knownUnsupported(capability Capability) error {
+	info := a.Panel()
+	if info != nil && !info.Capabilities.Has(capability) {
+		return &UnsupportedError{Capability: capability, Flavor: info.Flavor}
+	}
+	return nil
+}
+
+var (
+	clientEndpoints = []struct {
+		capability Capability
+		path       string
+	}{
+		{CapabilityActivityLogs, "/account/activity"},
+		{CapabilitySSHKeys, "/account/ssh-keys"},
+	}
+
+	pterodactylAccountKeys = []string{"id", "admin", "username", "email", "first_name",
+		"last_name", "language"}
+)
+
+// Probe checks that the panel is reachable and the key is valid by fetching
+// the account, then detects the flavor and capabilities with a request per
+// optional endpoint. Failures of the first request are a *ProbeError. The
+// result is kept for RequireCapability. Unless a header reports the version,
+// the endpoints give the range of Pterodactyl releases the panel can be.
+func (c *Client) Probe() (*PanelInfo, error) {
+	info, err := c.probe()
+	if err != nil {
+		return nil, err
+	}
+
+	c.panel.set(info)
+	return info, nil
+}
+
+func (c *Client) probe() (*PanelInfo, error) {
+	start := time.Now()
+	res, err := c.Http.Do(c.newRequest("GET", "/account", nil))
+	if err != nil {
+		return nil, &ProbeError{Kind: ErrUnreachable, Err: err}
+	}
+
+	info := &PanelInfo{Latency: time.Since(start), Capabilities: Capabilities{}}
+	detectFromHeaders(info, res.Header)
+
+	buf, err := probeResponse(res)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range clientEndpoints {
+		ok, err := endpointExists(c.Http, c.newRequest("GET", e.path, nil))
+		if err != nil {
+			return nil, err
+		}
+		info.Capabilities[e.capability] = ok
+	}
+
+	if info.Flavor == FlavorUnknown {
+		switch {
+		case hasPrefix(c.ApiKey, []string{"plcn_"}):
+			info.Flavor = FlavorPelican
+		case hasUnknownKeys(attributeKeys(buf), pterodactylAccountKeys):
+			info.Flavor = FlavorFork
+		default:
+			info.Flavor = FlavorPterodactyl
+		}
+	}
+	if info.Flavor == FlavorPterodactyl {
+		info.Version = pterodactylVersion(info.Version, info.Capabilities)
+	}
+
+	return info, nil
+}
+
+// Panel returns the result of the last probe or the flavor declared with
+// WithFlavor, or nil if there was neither.
+func (c *Client) Panel() *PanelInfo {
+	if c.panel == nil {
+		return nil
+	}
+
+	c.panel.mu.Lock()
+	defer c.panel.mu.Unlock()
+	return c.panel.info
+}
+
+// RequireCapability returns an *UnsupportedError if the panel lacks the
+// capability, the panel is probed on first use.
+func (c *Client) RequireCapability(capability Capability) error {
+	info, err := c.panel.get(c.probe)
+	if err != nil {
+		return err
+	}
+	if !info.Capabilities.Has(capability) {
+		return &UnsupportedError{Capability: capability, Flavor: info.Flavor}
+	}
+	return nil
+}
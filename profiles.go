@@ -0,0 +1,288 @@
+package crocgodyl
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds named panel profiles, usually read from a file such as:
+//
+//	default: production
+//	profiles:
+//	  production:
+//	    panel_url: https://panel.example.com
+//	    app_key: cmd:pass show panel/app
+//	    client_key: env:PANEL_CLIENT_KEY
+//	    timeout: 30s
+//	  staging:
+//	    panel_url: https://staging.example.com
+//	    app_key: file:~/.config/croc/staging.key
+//	    tls:
+//	      ca_file: /etc/ssl/staging-ca.pem
+type Config struct {
+	Default  string              `yaml:"default" json:"default"`
+	Profiles map[string]*Profile `yaml:"profiles" json:"profiles"`
+}
+
+type ProfileTLS struct {
+	CAFile             string `yaml:"ca_file" json:"ca_file"`
+	CertFile           string `yaml:"cert_file" json:"cert_file"`
+	KeyFile            string `yaml:"key_file" json:"key_file"`
+	ServerName         string `yaml:"server_name" json:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" json:"insecure_skip_verify"`
+}
+
+// Profile describes how to reach one panel. Keys are key sources rather than
+// the keys themselves, see ResolveKey. Timeout limits the wait for a response
+// and not the transfer of file contents. Flavor may be set to pterodactyl or
+// pelican to skip probing for capability checks.
+type Profile struct {
+	Name        string        `yaml:"-" json:"-"`
+	PanelURL    string        `yaml:"panel_url" json:"panel_url"`
+	AppKey      string        `yaml:"app_key" json:"app_key"`
+	ClientKey   string        `yaml:"client_key" json:"client_key"`
+	Timeout     time.Duration `yaml:"timeout" json:"timeout"`
+	DialTimeout time.Duration `yaml:"dial_timeout" json:"dial_timeout"`
+	Flavor      Flavor        `yaml:"flavor" json:"flavor"`
+	TLS         ProfileTLS    `yaml:"tls" json:"tls"`
+}
+
+// DefaultConfigPath returns the path of the config file used when none is
+// given, CROC_CONFIG overrides the default of croc/config.yml in the user's
+// config directory.
+func DefaultConfigPath() string {
+	if p := os.Getenv("CROC_CONFIG"); p != "" {
+		return p
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "croc", "config.yml")
+}
+
+// LoadConfig reads profiles from a YAML or JSON file. An empty path uses
+// DefaultConfigPath, which is allowed to not exist so that profiles can come
+// from the environment alone.
+func LoadConfig(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultConfigPath()
+	}
+
+	cfg := &Config{Profiles: map[string]*Profile{}}
+	if path == "" {
+		return cfg, nil
+	}
+
+	buf, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err = yaml.Unmarshal(buf, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]*Profile{}
+	}
+
+	return cfg, nil
+}
+
+// Profile returns a copy of the named profile with environment overrides
+// applied. Without a name CROC_PROFILE is used, then the config's default and
+// finally "default", which may be missing if the environment sets the URL.
+func (c *Config) Profile(name string) (*Profile, error) {
+	explicit := true
+	if name == "" {
+		name = os.Getenv("CROC_PROFILE")
+	}
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		name = "default"
+		explicit = false
+	}
+
+	p := &Profile{}
+	if found, ok := c.Profiles[name]; ok {
+		*p = *found
+	} else if explicit {
+		return nil, fmt.Errorf("profile %q does not exist", name)
+	}
+	p.Name = name
+
+	if err := p.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *Profile) applyEnv() error {
+	for env, field := range map[string]*string{
+		"CROC_PANEL_URL":  &p.PanelURL,
+		"CROC_APP_KEY":    &p.AppKey,
+		"CROC_CLIENT_KEY": &p.ClientKey,
+		"CROC_CA_FILE":    &p.TLS.CAFile,
+		"CROC_CERT_FILE":  &p.TLS.CertFile,
+		"CROC_KEY_FILE":   &p.TLS.KeyFile,
+	} {
+		if v, ok := os.LookupEnv(env); ok {
+			*field = v
+		}
+	}
+
+	if v := os.Getenv("CROC_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid CROC_TIMEOUT: %w", err)
+		}
+		p.Timeout = d
+	}
+
+	if v := os.Getenv("CROC_INSECURE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid CROC_INSECURE: %w", err)
+		}
+		p.TLS.InsecureSkipVerify = b
+	}
+
+	return nil
+}
+
+// ResolveKey returns the key a key source refers to:
+//
+//	env:NAME       the value of an environment variable
+//	file:PATH      the contents of a file, ~ expands to the home directory
+//	cmd:COMMAND    the output of a command, run without a shell
+//
+// Anything else is used as the key itself. Surrounding whitespace is removed.
+func ResolveKey(source string) (string, error) {
+	var key string
+	switch {
+	case strings.HasPrefix(source, "env:"):
+		name := strings.TrimPrefix(source, "env:")
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		key = v
+
+	case strings.HasPrefix(source, "file:"):
+		buf, err := os.ReadFile(expandHome(strings.TrimPrefix(source, "file:")))
+		if err != nil {
+			return "", err
+		}
+		key = string(buf)
+
+	case strings.HasPrefix(source, "cmd:"):
+		args := strings.Fields(strings.TrimPrefix(source, "cmd:"))
+		if len(args) == 0 {
+			return "", errors.New("empty key command")
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("key command %s failed: %w", args[0], err)
+		}
+		key = string(out)
+
+	default:
+		key = source
+	}
+
+	return strings.TrimSpace(key), nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// Options returns the client options for the profile's timeout and TLS
+// settings.
+func (p *Profile) Options() []Option {
+	var opts []Option
+	if p.Timeout > 0 {
+		opts = append(opts, WithResponseTimeout(p.Timeout))
+	}
+	if p.DialTimeout > 0 {
+		opts = append(opts, WithDialTimeout(p.DialTimeout))
+	}
+
+	if p.Flavor != FlavorUnknown {
+		opts = append(opts, WithFlavor(p.Flavor))
+	}
+
+	t := p.TLS
+	if t.ServerName != "" {
+		opts = append(opts, WithTLSConfig(&tls.Config{ServerName: t.ServerName}))
+	}
+	if t.CAFile != "" {
+		opts = append(opts, WithCAFile(expandHome(t.CAFile)))
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		opts = append(opts, WithClientCertificate(expandHome(t.CertFile), expandHome(t.KeyFile)))
+	}
+	if t.InsecureSkipVerify {
+		opts = append(opts, WithInsecureSkipVerify())
+	}
+
+	return opts
+}
+
+// Application creates an application api client for the profile, options are
+// applied after the profile's own.
+func (p *Profile) Application(opts ...Option) (*Application, error) {
+	if p.AppKey == "" {
+		return nil, fmt.Errorf("profile %q has no application key", p.Name)
+	}
+
+	key, err := ResolveKey(p.AppKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewApp(p.PanelURL, key, append(p.Options(), opts...)...)
+}
+
+// Client creates a client api client for the profile, options are applied
+// after the profile's own.
+func (p *Profile) Client(opts ...Option) (*Client, error) {
+	if p.ClientKey == "" {
+		return nil, fmt.Errorf("profile %q has no client key", p.Name)
+	}
+
+	key, err := ResolveKey(p.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(p.PanelURL, key, append(p.Options(), opts...)...)
+}
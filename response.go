@@ -0,0 +1,156 @@
+package crocgodyl
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Response is the metadata of a response recorded by a ResponseLog. Body
+// holds what the caller read of the body, up to the log's limit.
+type Response struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+	Truncated  bool
+	Duration   time.Duration
+}
+
+// ResponseLog records the responses of a client returned by Capture.
+// MaxBody limits how many bytes of each body are kept, 1 MiB if not set.
+type ResponseLog struct {
+	MaxBody int
+
+	mu        sync.Mutex
+	responses []*Response
+}
+
+const defaultMaxBody = 1 << 20
+
+// Responses returns the recorded responses in the order they were received.
+func (l *ResponseLog) Responses() []*Response {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := make([]*Response, 0, len(l.responses))
+	for _, r := range l.responses {
+		c := *r
+		c.Body = append([]byte(nil), r.Body...)
+		list = append(list, &c)
+	}
+	return list
+}
+
+// Last returns the most recent response, or nil if there was none.
+func (l *ResponseLog) Last() *Response {
+	list := l.Responses()
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+}
+
+func (l *ResponseLog) Reset() {
+	l.mu.Lock()
+	l.responses = nil
+	l.mu.Unlock()
+}
+
+func (l *ResponseLog) add(r *Response) {
+	l.mu.Lock()
+	l.responses = append(l.responses, r)
+	l.mu.Unlock()
+}
+
+type captureTransport struct {
+	base http.RoundTripper
+	log  *ResponseLog
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Response{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header.Clone(),
+		Duration:   time.Since(start),
+	}
+	t.log.add(r)
+
+	limit := t.log.MaxBody
+	if limit <= 0 {
+		limit = defaultMaxBody
+	}
+	res.Body = &captureBody{ReadCloser: res.Body, log: t.log, res: r, limit: limit}
+
+	return res, nil
+}
+
+// captureBody keeps a copy of the body as it is read by the caller.
+type captureBody struct {
+	io.ReadCloser
+	log   *ResponseLog
+	res   *Response
+	limit int
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.log.mu.Lock()
+		keep := n
+		if free := b.limit - len(b.res.Body); keep > free {
+			keep = free
+			b.res.Truncated = true
+		}
+		b.res.Body = append(b.res.Body, p[:keep]...)
+		b.log.mu.Unlock()
+	}
+	return n, err
+}
+
+func captureClient(client *http.Client, log *ResponseLog) *http.Client {
+	c := &http.Client{}
+	if client != nil {
+		*c = *client
+	}
+
+	base := c.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c.Transport = &captureTransport{base: base, log: log}
+
+	return c
+}
+
+// Capture returns a copy of the application that records every response in
+// the log, for inspecting the status, headers and raw body of a call:
+//
+//	var log crocgodyl.ResponseLog
+//	server, err := app.Capture(&log).GetServer(1)
+//	res := log.Last()
+func (a *Application) Capture(log *ResponseLog) *Application {
+	c := *a
+	c.Http = captureClient(a.Http, log)
+	return &c
+}
+
+// Capture returns a copy of the client that records every response in the
+// log, see Application.Capture.
+func (c *Client) Capture(log *ResponseLog) *Client {
+	cp := *c
+	cp.Http = captureClient(c.Http, log)
+	return &cp
+}
@@ -0,0 +1,170 @@
+package crocgodyl
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+var sshKeyTypes = map[string]bool{
+	"ssh-rsa":             true,
+	"ssh-ed25519":         true,
+	"ecdsa-sha2-nistp256": true,
+	"ecdsa-sha2-nistp384": true,
+	"ecdsa-sha2-nistp521": true,
+}
+
+type SSHPublicKey struct {
+	Type    string
+	Comment string
+	Bits    int
+	Blob    []byte
+}
+
+// ParseSSHPublicKey parses a single OpenSSH formatted public key, optionally
+// prefixed with authorized_keys options. Only key types accepted by the panel
+// are allowed.
+func ParseSSHPublicKey(line string) (*SSHPublicKey, error) {
+	fields := strings.Fields(line)
+	for i, f := range fields {
+		if i+1 >= len(fields) || !strings.HasPrefix(f, "ssh-") && !strings.HasPrefix(f, "ecdsa-") {
+			continue
+		}
+
+		blob, err := base64.StdEncoding.DecodeString(fields[i+1])
+		if err != nil {
+			continue
+		}
+
+		if f == "ssh-dss" {
+			return nil, errors.New("dsa keys are not supported")
+		}
+		if !sshKeyTypes[f] {
+			return nil, fmt.Errorf("unsupported ssh key type %q", f)
+		}
+
+		key := &SSHPublicKey{
+			Type:    f,
+			Comment: strings.Join(fields[i+2:], " "),
+			Blob:    blob,
+		}
+		if err = key.check(); err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	}
+
+	return nil, errors.New("no valid ssh public key found")
+}
+
+func (k *SSHPublicKey) check() error {
+	buf := k.Blob
+	name, buf, ok := readSSHString(buf)
+	if !ok || string(name) != k.Type {
+		return errors.New("ssh public key data does not match its type")
+	}
+
+	switch k.Type {
+	case "ssh-rsa":
+		var n []byte
+		if _, buf, ok = readSSHString(buf); ok {
+			n, _, ok = readSSHString(buf)
+		}
+		if !ok {
+			return errors.New("malformed rsa public key")
+		}
+
+		k.Bits = new(big.Int).SetBytes(n).BitLen()
+		if k.Bits < 2048 {
+			return errors.New("rsa keys must be at least 2048 bits")
+		}
+
+	case "ssh-ed25519":
+		point, _, ok := readSSHString(buf)
+		if !ok || len(point) != 32 {
+			return errors.New("malformed ed25519 public key")
+		}
+		k.Bits = 256
+
+	default:
+		curve, buf, ok := readSSHString(buf)
+		if !ok || k.Type != "ecdsa-sha2-"+string(curve) {
+			return errors.New("malformed ecdsa public key")
+		}
+		if _, _, ok = readSSHString(buf); !ok {
+			return errors.New("malformed ecdsa public key")
+		}
+
+		switch string(curve) {
+		case "nistp256":
+			k.Bits = 256
+		case "nistp384":
+			k.Bits = 384
+		case "nistp521":
+			k.Bits = 521
+		default:
+			return fmt.Errorf("unsupported ecdsa curve %q", curve)
+		}
+	}
+
+	return nil
+}
+
+func readSSHString(buf []byte) ([]byte, []byte, bool) {
+	if len(buf) < 4 {
+		return nil, nil, false
+	}
+
+	size := binary.BigEndian.Uint32(buf)
+	if uint32(len(buf)-4) < size {
+		return nil, nil, false
+	}
+
+	return buf[4 : 4+size], buf[4+size:], true
+}
+
+func (k *SSHPublicKey) Fingerprint() string {
+	sum := sha256.Sum256(k.Blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+func (k *SSHPublicKey) String() string {
+	return k.Type + " " + base64.StdEncoding.EncodeToString(k.Blob)
+}
+
+// normalizeFingerprint strips the prefix and padding so fingerprints from
+// ssh-keygen and the panel can be compared.
+func normalizeFingerprint(fp string) string {
+	return strings.TrimRight(strings.TrimPrefix(fp, "SHA256:"), "=")
+}
+
+func ParseAuthorizedKeys(r io.Reader) ([]*SSHPublicKey, error) {
+	var keys []*SSHPublicKey
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := string(bytes.TrimSpace(scanner.Bytes()))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, err := ParseSSHPublicKey(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, scanner.Err()
+}
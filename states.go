@@ -0,0 +1,204 @@
+package crocgodyl
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PowerSignal is a power action sent to a server.
+type PowerSignal string
+
+const (
+	PowerStart   PowerSignal = "start"
+	PowerStop    PowerSignal = "stop"
+	PowerRestart PowerSignal = "restart"
+	PowerKill    PowerSignal = "kill"
+)
+
+// ParsePowerSignal returns the signal of the given name, or an error for an
+// unknown signal.
+func ParsePowerSignal(name string) (PowerSignal, error) {
+	s := PowerSignal(name)
+	if !s.Valid() {
+		return "", fmt.Errorf("unknown power signal %q, expected start, stop, restart or kill", name)
+	}
+	return s, nil
+}
+
+func (s PowerSignal) String() string {
+	return string(s)
+}
+
+func (s PowerSignal) Valid() bool {
+	switch s {
+	case PowerStart, PowerStop, PowerRestart, PowerKill:
+		return true
+	}
+	return false
+}
+
+// Target returns the state the server ends up in once the signal is handled.
+func (s PowerSignal) Target() ServerState {
+	switch s {
+	case PowerStart, PowerRestart:
+		return StateRunning
+	case PowerStop, PowerKill:
+		return StateOffline
+	}
+	return ""
+}
+
+// MarshalJSON fails for unknown signals so they are not sent to the panel.
+func (s PowerSignal) MarshalJSON() ([]byte, error) {
+	if !s.Valid() {
+		return nil, fmt.Errorf("unknown power signal %q", string(s))
+	}
+	return json.Marshal(string(s))
+}
+
+// ServerState is the state of the server process reported by the daemon.
+type ServerState string
+
+const (
+	StateOffline  ServerState = "offline"
+	StateStarting ServerState = "starting"
+	StateRunning  ServerState = "running"
+	StateStopping ServerState = "stopping"
+)
+
+// ParseServerState returns the state of the given name, or an error for an
+// unknown state.
+func ParseServerState(name string) (ServerState, error) {
+	s := ServerState(name)
+	if !s.Valid() {
+		return "", fmt.Errorf("unknown server state %q, expected offline, starting, running or stopping", name)
+	}
+	return s, nil
+}
+
+func (s ServerState) String() string {
+	return string(s)
+}
+
+func (s ServerState) Valid() bool {
+	switch s {
+	case StateOffline, StateStarting, StateRunning, StateStopping:
+		return true
+	}
+	return false
+}
+
+func (s ServerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(s))
+}
+
+// Active reports whether the server process exists, which is the case in
+// every state but offline.
+func (s ServerState) Active() bool {
+	return s == StateStarting || s == StateRunning || s == StateStopping
+}
+
+// CanTransition reports whether the daemon moves a server from this state to
+// the next one. A process can exit at any time so every active state can go
+// to offline.
+func (s ServerState) CanTransition(next ServerState) bool {
+	switch s {
+	case StateOffline:
+		return next == StateStarting
+	case StateStarting:
+		return next == StateRunning || next == StateStopping || next == StateOffline
+	case StateRunning:
+		return next == StateStopping || next == StateOffline
+	case StateStopping:
+		return next == StateOffline
+	}
+	return false
+}
+
+// Accepts reports whether sending the signal in this state has an effect.
+// Restart is accepted in every state as the daemon starts an offline server.
+func (s ServerState) Accepts(signal PowerSignal) bool {
+	switch signal {
+	case PowerStart:
+		return s == StateOffline
+	case PowerStop:
+		return s == StateStarting || s == StateRunning
+	case PowerKill:
+		return s.Active()
+	case PowerRestart:
+		return s.Valid()
+	}
+	return false
+}
+
+// ServerStatus is the status of a server on the panel. It is empty for a
+// server that is installed and usable, the panel returns null in that case.
+type ServerStatus string
+
+const (
+	StatusNone            ServerStatus = ""
+	StatusInstalling      ServerStatus = "installing"
+	StatusInstallFailed   ServerStatus = "install_failed"
+	StatusReinstallFailed ServerStatus = "reinstall_failed"
+	StatusSuspended       ServerStatus = "suspended"
+	StatusRestoringBackup ServerStatus = "restoring_backup"
+)
+
+// ParseServerStatus returns the status of the given name, or an error for an
+// unknown status.
+func ParseServerStatus(name string) (ServerStatus, error) {
+	s := ServerStatus(name)
+	if !s.Valid() {
+		return "", fmt.Errorf("unknown server status %q", name)
+	}
+	return s, nil
+}
+
+func (s ServerStatus) String() string {
+	return string(s)
+}
+
+func (s ServerStatus) Valid() bool {
+	switch s {
+	case StatusNone, StatusInstalling, StatusInstallFailed, StatusReinstallFailed,
+		StatusSuspended, StatusRestoringBackup:
+		return true
+	}
+	return false
+}
+
+func (s ServerStatus) MarshalJSON() ([]byte, error) {
+	if s == StatusNone {
+		return []byte("null"), nil
+	}
+	return json.Marshal(string(s))
+}
+
+// Failed reports whether the last install of the server failed.
+func (s ServerStatus) Failed() bool {
+	return s == StatusInstallFailed || s == StatusReinstallFailed
+}
+
+// Available reports whether the server can be used, the panel rejects power
+// actions and file access for any other status.
+func (s ServerStatus) Available() bool {
+	return s == StatusNone
+}
+
+// CanTransition reports whether the panel moves a server from this status to
+// the next one.
+func (s ServerStatus) CanTransition(next ServerStatus) bool {
+	switch s {
+	case StatusNone:
+		return next == StatusInstalling || next == StatusSuspended || next == StatusRestoringBackup
+	case StatusInstalling:
+		return next == StatusNone || next == StatusInstallFailed || next == StatusReinstallFailed
+	case StatusInstallFailed, StatusReinstallFailed:
+		return next == StatusInstalling || next == StatusSuspended
+	case StatusSuspended:
+		return next == StatusNone
+	case StatusRestoringBackup:
+		return next == StatusNone
+	}
+	return false
+}
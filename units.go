@@ -0,0 +1,333 @@
+package crocgodyl
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The panel stores memory, swap and disk in MiB and CPU in percent of a core.
+// A limit of zero means unlimited, except for swap where zero disables swap
+// and -1 allows unlimited swap.
+const (
+	Unlimited     int64 = 0
+	UnlimitedSwap int64 = -1
+)
+
+// MiB is an amount of memory or disk space in mebibytes.
+type MiB int64
+
+const (
+	bytesPerMiB = 1 << 20
+	mibPerGiB   = 1 << 10
+	mibPerTiB   = 1 << 20
+)
+
+var sizeUnits = map[string]float64{
+	"":    1,
+	"m":   1,
+	"mb":  1,
+	"mib": 1,
+	"g":   mibPerGiB,
+	"gb":  mibPerGiB,
+	"gib": mibPerGiB,
+	"t":   mibPerTiB,
+	"tb":  mibPerTiB,
+	"tib": mibPerTiB,
+}
+
+// ParseMiB parses a size such as "512", "512MiB", "4GiB" or "1.5G". A number
+// without a unit is in MiB, and like on the panel M, MB and MiB all mean
+// mebibytes. Sizes must be whole MiB and not negative.
+func ParseMiB(s string) (MiB, error) {
+	v := strings.TrimSpace(s)
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(v)
+	}
+
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(v[i:]))]
+	if !ok || i == 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	n, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	size := n * unit
+	if size != math.Trunc(size) || size > math.MaxInt64/bytesPerMiB {
+		return 0, fmt.Errorf("invalid size %q, sizes must be whole MiB", s)
+	}
+
+	return MiB(size), nil
+}
+
+// BytesToMiB converts bytes such as the resource usage of a server to MiB,
+// rounding down.
+func BytesToMiB(bytes int64) MiB {
+	return MiB(bytes / bytesPerMiB)
+}
+
+func (m MiB) Bytes() int64 {
+	return int64(m) * bytesPerMiB
+}
+
+// String formats the size in the largest unit that divides it, such as
+// "4GiB" or "1536MiB".
+func (m MiB) String() string {
+	switch {
+	case m != 0 && m%mibPerTiB == 0:
+		return fmt.Sprintf("%dTiB", m/mibPerTiB)
+	case m != 0 && m%mibPerGiB == 0:
+		return fmt.Sprintf("%dGiB", m/mibPerGiB)
+	}
+	return fmt.Sprintf("%dMiB", int64(m))
+}
+
+// ParseMemory parses a memory or disk limit, "unlimited" and "0" are both
+// returned as Unlimited.
+func ParseMemory(s string) (int64, error) {
+	if strings.EqualFold(strings.TrimSpace(s), "unlimited") {
+		return Unlimited, nil
+	}
+
+	m, err := ParseMiB(s)
+	return int64(m), err
+}
+
+// FormatMemory formats a memory or disk limit in MiB.
+func FormatMemory(v int64) string {
+	if v == Unlimited {
+		return "unlimited"
+	}
+	return MiB(v).String()
+}
+
+// ParseSwap parses a swap limit, "unlimited" and "-1" are returned as
+// UnlimitedSwap while "0" or "none" disables swap.
+func ParseSwap(s string) (int64, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "unlimited", "-1":
+		return UnlimitedSwap, nil
+	case "none":
+		return 0, nil
+	}
+
+	m, err := ParseMiB(s)
+	return int64(m), err
+}
+
+func FormatSwap(v int64) string {
+	switch v {
+	case UnlimitedSwap:
+		return "unlimited"
+	case 0:
+		return "none"
+	}
+	return MiB(v).String()
+}
+
+// ParseCPU parses a CPU limit in percent of a core such as "150%" or "150",
+// "unlimited" and "0" are returned as Unlimited.
+func ParseCPU(s string) (int64, error) {
+	v := strings.TrimSpace(s)
+	if strings.EqualFold(v, "unlimited") {
+		return Unlimited, nil
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSuffix(v, "%"), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid cpu limit %q", s)
+	}
+	return n, nil
+}
+
+func FormatCPU(v int64) string {
+	if v == Unlimited {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d%%", v)
+}
+
+// CPUSet is a list of CPU threads a server is pinned to, empty when the server
+// can use every thread.
+type CPUSet []int
+
+const maxCPUThread = 1<<12 - 1
+
+// ParseCPUSet parses a list of threads and ranges such as "0-3,6". An empty
+// string returns an empty set.
+func ParseCPUSet(s string) (CPUSet, error) {
+	seen := map[int]bool{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+
+		first, last := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			first, last = part[:i], part[i+1:]
+		}
+
+		lo, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil || lo < 0 {
+			return nil, fmt.Errorf("invalid cpu threads %q", s)
+		}
+		hi, err := strconv.Atoi(strings.TrimSpace(last))
+		if err != nil || hi < lo || hi > maxCPUThread {
+			return nil, fmt.Errorf("invalid cpu threads %q", s)
+		}
+
+		for t := lo; t <= hi; t++ {
+			seen[t] = true
+		}
+	}
+
+	set := make(CPUSet, 0, len(seen))
+	for t := range seen {
+		set = append(set, t)
+	}
+	sort.Ints(set)
+
+	return set, nil
+}
+
+// String formats the set the way the panel stores it, with consecutive
+// threads joined into ranges such as "0-3,6".
+func (c CPUSet) String() string {
+	parts := make([]string, 0, len(c))
+	for i := 0; i < len(c); {
+		j := i
+		for j+1 < len(c) && c[j+1] == c[j]+1 {
+			j++
+		}
+
+		if j > i {
+			parts = append(parts, fmt.Sprintf("%d-%d", c[i], c[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(c[i]))
+		}
+		i = j + 1
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// CPUSet returns the threads the server is pinned to.
+func (l Limits) CPUSet() (CPUSet, error) {
+	return ParseCPUSet(l.Threads)
+}
+
+func (l Limits) MemoryUnlimited() bool {
+	return l.Memory == Unlimited
+}
+
+func (l Limits) SwapUnlimited() bool {
+	return l.Swap == UnlimitedSwap
+}
+
+func (l Limits) DiskUnlimited() bool {
+	return l.Disk == Unlimited
+}
+
+func (l Limits) CPUUnlimited() bool {
+	return l.CPU == Unlimited
+}
+
+// Validate checks the limits against the rules of the panel, and that a CPU
+// limit is not more than the pinned threads can use.
+func (l Limits) Validate() error {
+	var errs []string
+	if l.Memory < 0 {
+		errs = append(errs, "memory must not be negative")
+	}
+	if l.Swap < UnlimitedSwap {
+		errs = append(errs, "swap must be -1 for unlimited or more")
+	}
+	if l.Disk < 0 {
+		errs = append(errs, "disk must not be negative")
+	}
+	if l.IO < 10 || l.IO > 1000 {
+		errs = append(errs, "io must be between 10 and 1000")
+	}
+	if l.CPU < 0 {
+		errs = append(errs, "cpu must not be negative")
+	}
+	if set, err := l.CPUSet(); err != nil {
+		errs = append(errs, err.Error())
+	} else if len(set) > 0 && l.CPU > int64(len(set))*100 {
+		errs = append(errs, fmt.Sprintf("cpu limit %s is more than the %d pinned threads can use", FormatCPU(l.CPU), len(set)))
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid limits: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// ValidateResources checks the memory and disk of the node against the rules
+// of the panel. An overallocation of -1 disables the check for that resource.
+func (n *Node) ValidateResources() error {
+	var errs []string
+	if n.Memory <= 0 {
+		errs = append(errs, "memory must be more than zero")
+	}
+	if n.Disk <= 0 {
+		errs = append(errs, "disk must be more than zero")
+	}
+	if n.MemoryOverallocate < -1 {
+		errs = append(errs, "memory overallocation must be -1 or more")
+	}
+	if n.DiskOverallocate < -1 {
+		errs = append(errs, "disk overallocation must be -1 or more")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid node resources: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// MemoryFraction returns the used memory as a fraction of the limit, ok is
+// false when the memory is unlimited.
+func (u ResourceUsage) MemoryFraction(l Limits) (fraction float64, ok bool) {
+	if l.MemoryUnlimited() {
+		return 0, false
+	}
+	return float64(u.MemoryBytes) / float64(MiB(l.Memory).Bytes()), true
+}
+
+// DiskFraction returns the used disk space as a fraction of the limit, ok is
+// false when the disk is unlimited.
+func (u ResourceUsage) DiskFraction(l Limits) (fraction float64, ok bool) {
+	if l.DiskUnlimited() {
+		return 0, false
+	}
+	return float64(u.DiskBytes) / float64(MiB(l.Disk).Bytes()), true
+}
+
+// CPUFraction returns the used CPU as a fraction of the limit, ok is false
+// when the CPU is unlimited.
+func (u ResourceUsage) CPUFraction(l Limits) (fraction float64, ok bool) {
+	if l.CPUUnlimited() {
+		return 0, false
+	}
+	return u.CPUAbsolute / float64(l.CPU), true
+}
+
+// Exceeds reports whether the usage is above any limit that is set.
+func (u ResourceUsage) Exceeds(l Limits) bool {
+	for _, f := range []func(Limits) (float64, bool){u.MemoryFraction, u.DiskFraction, u.CPUFraction} {
+		if fraction, ok := f(l); ok && fraction > 1 {
+			return true
+		}
+	}
+	return false
+}